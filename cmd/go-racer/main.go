@@ -3,10 +3,19 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	"go-racer/pkg/config"
+	"go-racer/pkg/game"
 	"go-racer/pkg/plugins"
+	"go-racer/pkg/race"
+	"go-racer/pkg/store"
 	"go-racer/pkg/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,26 +26,353 @@ func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		// Ignore error, use default
-		cfg = &config.Config{LastPlugin: "hn"}
+		cfg = config.Default()
 	}
 
-	pluginName := flag.String("plugin", cfg.LastPlugin, "Plugin source to use (hn, github, spanish-news)")
+	// Run history lives in its own store; without it runs are kept in memory only
+	st, err := store.OpenDefault()
+	if err != nil {
+		fmt.Printf("Warning: run history unavailable: %v\n", err)
+	}
+
+	// Executables in the plugin directory join the built-in plugins, with
+	// WebAssembly ones compiled once and kept in the cache directory
+	if dir, err := config.CacheDir(); err == nil {
+		plugins.SetWasmCacheDir(filepath.Join(dir, "wasm"))
+	}
+	if dir, err := config.PluginDir(); err == nil {
+		if err := plugins.LoadExternal(dir); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			serve(cfg, st, os.Args[2:])
+			return
+		case "join":
+			join(cfg, st, os.Args[2:])
+			return
+		}
+	}
+
+	pluginName := flag.String("plugin", cfg.LastPlugin, "Plugin source to use ("+strings.Join(plugins.ListPlugins(), ", ")+", or list to describe them)")
+	timeLimit := flag.Int("time", 0, "Run timed tests of this many seconds ("+joinInts(game.TimeLimits)+"), saved as the default")
+	wordCount := flag.Int("words", 0, "Type this many words stitched from several items ("+joinInts(game.WordCounts)+"), saved as the default")
+	ghostWPM := flag.Int("ghost-wpm", 0, "Race a ghost typing at this many WPM, saved as the default")
+	fetchTimeout := flag.Int("fetch-timeout", 0, "Seconds to wait for a plugin before showing an error, saved as the default")
+	textFile := flag.String("file", "", "Type the text in this file instead of a plugin's")
+	text := flag.String("text", "", "Type this text instead of a plugin's")
+	var pluginOpts optionFlags
+	flag.Var(&pluginOpts, "plugin-opt", "Set a plugin option as key=value (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: go-racer [flags] [-]\n\nWith - or when text is piped in, the text to type is read from standard input.")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
-	// Update config with the selected plugin (whether from flag or default)
-	if *pluginName != cfg.LastPlugin {
-		cfg.LastPlugin = *pluginName
+	checkFlag("time", *timeLimit, game.TimeLimits)
+	checkFlag("words", *wordCount, game.WordCounts)
+	checkFlag("ghost-wpm", *ghostWPM, nil)
+	checkFlag("fetch-timeout", *fetchTimeout, nil)
+
+	// Like changes on the settings screen, these are kept for later sessions
+	if *ghostWPM > 0 {
+		cfg.Ghost = config.GhostPace
+		cfg.GhostWPM = *ghostWPM
+	}
+	if *fetchTimeout > 0 {
+		cfg.FetchTimeout = *fetchTimeout
+	}
+	if *timeLimit > 0 {
+		cfg.Mode = config.ModeTime
+		cfg.TimeLimit = *timeLimit
+	} else if *wordCount > 0 {
+		cfg.Mode = config.ModeWords
+		cfg.WordCount = *wordCount
+	}
+	if *ghostWPM > 0 || *fetchTimeout > 0 || *timeLimit > 0 || *wordCount > 0 {
 		_ = config.Save(cfg)
 	}
 
-	plugin, err := plugins.GetPlugin(*pluginName)
+	// Without an explicit -plugin, start on the home screen to pick one
+	args := flag.Args()
+	pluginSet, textSet := false, len(args) > 0
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "plugin", "plugin-opt":
+			pluginSet = true
+		case "file", "text":
+			textSet = true
+		}
+	})
+	if pluginSet && textSet {
+		fmt.Println("Error: -plugin can't be used with -file, -text or -")
+		os.Exit(2)
+	}
+	// Text piped in is typed as if - was given
+	if !pluginSet && !textSet && stdinPiped() {
+		args, textSet = []string{"-"}, true
+	}
+	if textSet {
+		source, name, fromStdin := loadText(*textFile, *text, args)
+		m := ui.InitialModel(source, name, cfg, st)
+		m.CacheDir, _ = config.CacheDir()
+		if fromStdin {
+			// Standard input is used up, so keys come from the terminal itself
+			run(m, tea.WithInputTTY())
+		} else {
+			run(m)
+		}
+		return
+	}
+	if !pluginSet {
+		home := ui.InitialHomeModel(cfg, st)
+		home.CacheDir, _ = config.CacheDir()
+		run(home)
+		return
+	}
+
+	plugin, name := loadPlugin(cfg, *pluginName, pluginOpts)
+
+	// Update config with the selected plugin
+	cfg.UsePlugin(name)
+	_ = config.Save(cfg)
+
+	m := ui.InitialModel(plugin, name, cfg, st)
+	m.CacheDir, _ = config.CacheDir()
+	run(m)
+}
+
+// serve hosts a LAN race room and joins it as the first racer
+func serve(cfg *config.Config, st *store.Store, args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":"+strconv.Itoa(race.DefaultPort), "Address to listen on")
+	name := fs.String("name", defaultRacerName(), "Your name in the race")
+	pluginName := fs.String("plugin", cfg.LastPlugin, "Plugin source the race texts come from")
+	var pluginOpts optionFlags
+	fs.Var(&pluginOpts, "plugin-opt", "Set a plugin option as key=value (repeatable)")
+	_ = fs.Parse(args)
+
+	plugin, _ := loadPlugin(cfg, *pluginName, pluginOpts)
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	srv := race.NewServer(plugin)
+	srv.Prepare = func(content *plugins.Content) { ui.PrepareContent(content, cfg) }
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	client, err := race.Dial(net.JoinHostPort("127.0.0.1", port), *name)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		fmt.Println("Available plugins:", plugins.ListPlugins())
 		os.Exit(1)
 	}
+	defer client.Close()
+
+	run(ui.InitialRaceModel(client, srv, race.LocalAddrs(port), cfg, st))
+}
+
+// join connects to a race hosted with `go-racer serve`
+func join(cfg *config.Config, st *store.Store, args []string) {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-racer join [-name NAME] <host[:port]>")
+		fs.PrintDefaults()
+	}
+	name := fs.String("name", defaultRacerName(), "Your name in the race")
+	_ = fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	addr := fs.Arg(0)
+	if !strings.Contains(addr, ":") {
+		addr = net.JoinHostPort(addr, strconv.Itoa(race.DefaultPort))
+	}
+
+	client, err := race.Dial(addr, *name)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	run(ui.InitialRaceModel(client, nil, nil, cfg, st))
+}
+
+// checkFlag exits if a number flag was given a negative value or, when
+// options are listed, one that isn't among them
+func checkFlag(name string, value int, options []int) {
+	switch {
+	case value < 0:
+		fmt.Printf("Error: -%s must be a positive number, got %d\n", name, value)
+	case value > 0 && options != nil && !slices.Contains(options, value):
+		fmt.Printf("Error: -%s must be one of %s, got %d\n", name, joinInts(options), value)
+	default:
+		return
+	}
+	os.Exit(2)
+}
+
+func joinInts(values []int) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ", ")
+}
+
+// optionFlags collects repeated -plugin-opt key=value flags
+type optionFlags []string
+
+func (o *optionFlags) String() string { return strings.Join(*o, ", ") }
+
+func (o *optionFlags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// loadPlugin creates the plugin registered under name or an alias, saving
+// any options given on the command line before applying the stored ones. It
+// returns the plugin and its canonical name, and exits on invalid input.
+func loadPlugin(cfg *config.Config, name string, opts optionFlags) (plugins.ContentSource, string) {
+	if name == "list" {
+		printPlugins()
+		os.Exit(0)
+	}
+
+	reg, ok := plugins.Lookup(name)
+	if !ok {
+		fmt.Printf("Error: unknown plugin: %s\n", name)
+		printPlugins()
+		os.Exit(1)
+	}
+	name = reg.Name
+	plugin := reg.Factory()
+
+	if len(opts) > 0 {
+		c, ok := plugin.(plugins.Configurable)
+		if !ok {
+			fmt.Printf("Error: plugin %s has no options\n", name)
+			os.Exit(1)
+		}
+		for _, opt := range opts {
+			key, value, _ := strings.Cut(opt, "=")
+			setting, ok := plugins.FindSetting(c, key)
+			if !ok {
+				fmt.Printf("Error: plugin %s has no option %q\n", name, key)
+				printSettings(c)
+				os.Exit(1)
+			}
+			if err := setting.Validate(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			cfg.SetPluginOption(name, key, value)
+		}
+		_ = config.Save(cfg)
+	}
+
+	if err := plugins.Configure(plugin, cfg.PluginOptions[name]); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Without a cache directory plugins simply fetch every time
+	cacheDir, _ := config.CacheDir()
+	return plugins.WithCache(name, plugin, cfg.PluginOptions[name], cacheDir), name
+}
+
+// loadText wraps the text given with -file, -text or piped in with - as a
+// source, named for history as "file:<name>", "text" or "stdin". It reports
+// whether the text came from standard input, and exits on invalid input.
+func loadText(file, text string, args []string) (plugins.ContentSource, string, bool) {
+	given := 0
+	for _, set := range []bool{file != "", text != "", len(args) > 0} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 || len(args) > 1 || (len(args) == 1 && args[0] != "-") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var source *plugins.TextSource
+	var name string
+	var err error
+	switch {
+	case file != "":
+		var data []byte
+		if data, err = os.ReadFile(file); err == nil {
+			path, _ := filepath.Abs(file)
+			name = "file:" + filepath.Base(file)
+			source, err = plugins.NewTextSource(filepath.Base(file), path, string(data))
+		}
+	case text != "":
+		name = "text"
+		source, err = plugins.NewTextSource("Text", "", text)
+	default:
+		var data []byte
+		if data, err = io.ReadAll(os.Stdin); err == nil {
+			name = "stdin"
+			source, err = plugins.NewTextSource("Standard input", "", string(data))
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return source, name, len(args) > 0
+}
+
+// stdinPiped reports whether standard input is a pipe or file rather than a terminal
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
+
+func printPlugins() {
+	fmt.Println("Available plugins:")
+	for _, r := range plugins.Registrations() {
+		name := r.Name
+		if len(r.Aliases) > 0 {
+			name += " (" + strings.Join(r.Aliases, ", ") + ")"
+		}
+		fmt.Printf("  %-22s %s\n", name, r.Description)
+	}
+}
+
+func printSettings(c plugins.Configurable) {
+	fmt.Println("Available options:")
+	for _, s := range c.Settings() {
+		fmt.Printf("  %-10s %s (%s, default %q)\n", s.Key, s.Description, s.Type, s.Default)
+	}
+}
+
+func defaultRacerName() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	if host, err := os.Hostname(); err == nil {
+		return host
+	}
+	return "racer"
+}
 
-	p := tea.NewProgram(ui.InitialModel(plugin, *pluginName, cfg))
+func run(model ui.Model, opts ...tea.ProgramOption) {
+	p := tea.NewProgram(model, opts...)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
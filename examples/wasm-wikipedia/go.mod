@@ -0,0 +1,3 @@
+module go-racer/examples/wasm-wikipedia
+
+go 1.24
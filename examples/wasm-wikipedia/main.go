@@ -0,0 +1,172 @@
+// Command wasm-wikipedia is a sample go-racer plugin compiled to WebAssembly.
+// It types out the summary of a random Wikipedia article, fetched through
+// the host, which only lets it reach the hosts the user allows.
+//
+// Build it as a WASI reactor and copy it to the plugin directory:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o wikipedia.wasm
+//	cp wikipedia.wasm ~/.config/go-racer/plugins/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+const randomURL = "https://%s.wikipedia.org/api/rest_v1/page/random/summary"
+
+func main() {}
+
+// fetch GETs a URL through the host. It returns the length of the body, or
+// minus the length of an error message, to be copied out with take.
+//
+//go:wasmimport go_racer fetch
+func fetch(urlPtr, urlLen uint32) int32
+
+// take copies the result of the last fetch to buf
+//
+//go:wasmimport go_racer take
+func take(bufPtr uint32)
+
+// pinned keeps memory handed to the host alive until the next request
+var pinned [][]byte
+
+//go:wasmexport alloc
+func alloc(size uint32) uint32 {
+	buf := make([]byte, max(size, 1))
+	pinned = append(pinned, buf)
+	return pointer(buf)
+}
+
+// handle answers one JSON request and returns the answer's address in the
+// high 32 bits and its length in the low 32 bits
+//
+//go:wasmexport handle
+func handle(reqPtr unsafe.Pointer, reqLen uint32) uint64 {
+	req := unsafe.Slice((*byte)(reqPtr), reqLen)
+	answer, err := json.Marshal(respond(req))
+	if err != nil {
+		answer = []byte(`{"error": "failed to encode answer"}`)
+	}
+	pinned = [][]byte{answer}
+	return uint64(pointer(answer))<<32 | uint64(len(answer))
+}
+
+type request struct {
+	Method   string            `json:"method"`
+	Settings map[string]string `json:"settings"`
+}
+
+type setting struct {
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type content struct {
+	Text      string `json:"text"`
+	SourceURL string `json:"source_url,omitempty"`
+	Author    string `json:"author,omitempty"`
+}
+
+type answer struct {
+	Error       string    `json:"error,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Online      bool      `json:"online,omitempty"`
+	Hosts       []string  `json:"hosts,omitempty"`
+	Settings    []setting `json:"settings,omitempty"`
+	Content     *content  `json:"content,omitempty"`
+}
+
+func respond(raw []byte) answer {
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return answer{Error: err.Error()}
+	}
+
+	switch req.Method {
+	case "describe":
+		return answer{
+			Name:        "Wikipedia",
+			Description: "Summaries of random Wikipedia articles",
+			Language:    "en",
+			Online:      true,
+			Hosts:       []string{"*.wikipedia.org"},
+			Settings: []setting{
+				{Key: "lang", Label: "Language", Description: "Wikipedia edition to read from", Type: "enum", Default: "en", Options: []string{"en", "es", "de", "fr"}},
+				{Key: "min_words", Label: "Minimum words", Description: "Skip shorter summaries", Type: "int", Default: "20"},
+			},
+		}
+	case "get_content":
+		c, err := randomSummary(req.Settings)
+		if err != nil {
+			return answer{Error: err.Error()}
+		}
+		return answer{Content: c}
+	}
+	return answer{Error: "unknown method " + req.Method}
+}
+
+// randomSummary tries a few random articles for one with a long enough summary
+func randomSummary(settings map[string]string) (*content, error) {
+	lang := settings["lang"]
+	if lang == "" {
+		lang = "en"
+	}
+	minWords, _ := strconv.Atoi(settings["min_words"])
+
+	for attempt := 0; attempt < 5; attempt++ {
+		body, err := get(fmt.Sprintf(randomURL, lang))
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Title       string `json:"title"`
+			Extract     string `json:"extract"`
+			ContentURLs struct {
+				Desktop struct {
+					Page string `json:"page"`
+				} `json:"desktop"`
+			} `json:"content_urls"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		if len(strings.Fields(page.Extract)) >= minWords {
+			return &content{Text: page.Extract, SourceURL: page.ContentURLs.Desktop.Page, Author: page.Title}, nil
+		}
+	}
+	return nil, errors.New("no summary long enough")
+}
+
+func get(url string) ([]byte, error) {
+	u := []byte(url)
+	n := fetch(pointer(u), uint32(len(u)))
+	buf := make([]byte, abs(n)+1) // Never empty, so it has an address
+	take(pointer(buf))
+	buf = buf[:abs(n)]
+	if n < 0 {
+		return nil, errors.New(string(buf))
+	}
+	return buf, nil
+}
+
+func pointer(b []byte) uint32 {
+	return uint32(uintptr(unsafe.Pointer(unsafe.SliceData(b))))
+}
+
+func abs(n int32) int {
+	if n < 0 {
+		return int(-n)
+	}
+	return int(n)
+}
@@ -2,41 +2,216 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
-const configFileName = ".go-racer.json"
+const (
+	appName        = "go-racer"
+	configFileName = "config.json"
+	legacyFileName = ".go-racer.json"
+)
+
+// Test modes
+const (
+	ModeText  = "text"  // Ends when the whole text has been typed
+	ModeTime  = "time"  // Ends when the time limit runs out
+	ModeWords = "words" // A fixed number of words stitched from several items
+)
+
+// Ghost opponents
+const (
+	GhostOff  = ""
+	GhostBest = "best" // Personal best on the same text, falling back to the target pace
+	GhostPace = "pace" // A fixed target WPM
+)
 
 type CharMetric struct {
 	Attempts int `json:"attempts"`
 	Mistakes int `json:"mistakes"`
 }
 
+// Keystroke kinds recorded in a session's event log
+const (
+	KeystrokeInsert     = "insert"
+	KeystrokeBackspace  = "backspace"
+	KeystrokeDeleteWord = "delete_word"
+	KeystrokeIndent     = "indent" // Whitespace filled in by auto-indent
+	KeystrokeFinish     = "finish"
+)
+
+// Keystroke is a single entry in a session's event log.
+// At is the offset from the start of the test, taken from the monotonic clock.
+type Keystroke struct {
+	Kind string        `json:"kind"`
+	Rune string        `json:"rune,omitempty"`
+	At   time.Duration `json:"at"`
+}
+
 type GameResult struct {
-	WPM       float64 `json:"wpm"`
-	Accuracy  float64 `json:"accuracy"`
-	Timestamp int64   `json:"timestamp"`
+	WPM        float64               `json:"wpm"`
+	Accuracy   float64               `json:"accuracy"`
+	Timestamp  int64                 `json:"timestamp"`
+	Plugin     string                `json:"plugin,omitempty"`
+	Mode       string                `json:"mode,omitempty"` // See Config.ModeKey; empty for untimed text
+	Text       string                `json:"text,omitempty"`
+	Keystrokes []Keystroke           `json:"keystrokes,omitempty"`
+	CharStats  map[string]CharMetric `json:"char_stats,omitempty"` // Per-character first-try results for this run
+}
+
+// Duration returns how long the recorded session lasted, or 0 if it has no keystroke log
+func (r GameResult) Duration() time.Duration {
+	if len(r.Keystrokes) == 0 {
+		return 0
+	}
+	return r.Keystrokes[len(r.Keystrokes)-1].At
 }
 
+// Version is the current layout of config.json
+const Version = 1
+
+// Config holds user settings. Run data lives in the store package.
 type Config struct {
-	LastPlugin              string                `json:"last_plugin"`
-	Metrics                 map[string]CharMetric `json:"metrics"`
-	History                 []GameResult          `json:"history"`
-	IncludeNumbers          bool                  `json:"include_numbers"`
-	IncludePunctuation      bool                  `json:"include_punctuation"`
-	IncludeCapitalLetters   bool                  `json:"include_capital_letters"`
-	IncludeNonStandardChars bool                  `json:"include_non_standard_chars"`
+	Version                 int                          `json:"version"`
+	LastPlugin              string                       `json:"last_plugin"`
+	RecentPlugins           []string                     `json:"recent_plugins,omitempty"` // Most recent first
+	IncludeNumbers          bool                         `json:"include_numbers"`
+	IncludePunctuation      bool                         `json:"include_punctuation"`
+	IncludeCapitalLetters   bool                         `json:"include_capital_letters"`
+	IncludeNonStandardChars bool                         `json:"include_non_standard_chars"`
+	Mode                    string                       `json:"mode,omitempty"`
+	TimeLimit               int                          `json:"time_limit,omitempty"` // Seconds, for the "time" mode
+	WordCount               int                          `json:"word_count,omitempty"` // For the "words" mode
+	Ghost                   string                       `json:"ghost,omitempty"`
+	GhostWPM                int                          `json:"ghost_wpm,omitempty"`
+	FetchTimeout            int                          `json:"fetch_timeout,omitempty"`  // Seconds to wait for a plugin before giving up
+	PluginOptions           map[string]map[string]string `json:"plugin_options,omitempty"` // Plugin name -> setting key -> value
+	TabWidth                int                          `json:"tab_width,omitempty"`      // Spaces per tab in code
+	AutoIndent              bool                         `json:"auto_indent,omitempty"`    // Skip the indentation of each line of code
+}
+
+// Legacy is the single ~/.go-racer.json file used before settings and run data
+// were split. It is only read, to migrate existing users.
+type Legacy struct {
+	Config
+	Metrics map[string]CharMetric `json:"metrics"`
+	History []GameResult          `json:"history"`
+}
+
+// ModeKey identifies the test mode results were recorded under, so that only
+// comparable runs are shown together. Plain text tests have an empty key.
+func (c *Config) ModeKey() string {
+	switch c.Mode {
+	case ModeTime:
+		return fmt.Sprintf("time:%d", c.TimeLimit)
+	case ModeWords:
+		return fmt.Sprintf("words:%d", c.WordCount)
+	default:
+		return ""
+	}
+}
+
+// maxRecentPlugins bounds how many plugins RecentPlugins remembers
+const maxRecentPlugins = 5
+
+// UsePlugin records name as the last used plugin and moves it to the front
+// of the recent plugins
+func (c *Config) UsePlugin(name string) {
+	c.LastPlugin = name
+	recent := []string{name}
+	for _, p := range c.RecentPlugins {
+		if p != name && len(recent) < maxRecentPlugins {
+			recent = append(recent, p)
+		}
+	}
+	c.RecentPlugins = recent
+}
+
+// SetPluginOption stores the value of one setting of the named plugin
+func (c *Config) SetPluginOption(plugin, key, value string) {
+	if c.PluginOptions == nil {
+		c.PluginOptions = make(map[string]map[string]string)
+	}
+	if c.PluginOptions[plugin] == nil {
+		c.PluginOptions[plugin] = make(map[string]string)
+	}
+	c.PluginOptions[plugin][key] = value
+}
+
+// Default returns the settings used on a fresh install
+func Default() *Config {
+	return &Config{
+		Version:                 Version,
+		LastPlugin:              "words",
+		IncludeNumbers:          true,
+		IncludePunctuation:      true,
+		IncludeCapitalLetters:   true,
+		IncludeNonStandardChars: true,
+		Mode:                    ModeText,
+		TimeLimit:               30,
+		WordCount:               25,
+		GhostWPM:                60,
+		FetchTimeout:            15,
+		TabWidth:                4,
+	}
+}
+
+// ConfigDir returns the directory for settings, $XDG_CONFIG_HOME/go-racer or ~/.config/go-racer
+func ConfigDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataDir returns the directory for run data, $XDG_DATA_HOME/go-racer or ~/.local/share/go-racer
+func DataDir() (string, error) {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// CacheDir returns the directory for cached content, $XDG_CACHE_HOME/go-racer or ~/.cache/go-racer
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// PluginDir returns the directory external plugins, .wasm modules and API definitions are loaded from
+func PluginDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins"), nil
+}
+
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fallback, appName), nil
 }
 
 func GetConfigPath() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// LegacyPath returns the location of the pre-split single-file config
+func LegacyPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, configFileName), nil
+	return filepath.Join(home, legacyFileName), nil
 }
 
+// Load reads the settings, importing them from the legacy single-file config
+// on first run. Fields missing from the file keep their defaults.
 func Load() (*Config, error) {
 	path, err := GetConfigPath()
 	if err != nil {
@@ -45,35 +220,91 @@ func Load() (*Config, error) {
 
 	data, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
-		return &Config{
-			LastPlugin:              "hn",
-			IncludeNumbers:          true,
-			IncludePunctuation:      true,
-			IncludeCapitalLetters:   true,
-			IncludeNonStandardChars: true,
-		}, nil // Default
+		return loadLegacy()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// loadLegacy migrates settings from ~/.go-racer.json, or returns the defaults
+// if there is nothing to migrate
+func loadLegacy() (*Config, error) {
+	path, err := LegacyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Default(), nil
 	}
 	if err != nil {
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
+	legacy := Legacy{Config: *Default()}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	cfg := legacy.Config
+	cfg.Version = Version
+	if err := Save(&cfg); err != nil {
 		return nil, err
 	}
 	return &cfg, nil
 }
 
+// Save writes the settings atomically, so a crash never leaves a half-written file
 func Save(cfg *Config) error {
 	path, err := GetConfigPath()
 	if err != nil {
 		return err
 	}
 
+	cfg.Version = Version
 	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return WriteFileAtomic(path, data, 0644)
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it
+// into place, creating the parent directory if needed
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tempHome points the config directory and home at empty temporary
+// directories, returning both
+func tempHome(t *testing.T) (configDir, home string) {
+	t.Helper()
+	configDir, home = t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("HOME", home)
+	return filepath.Join(configDir, appName), home
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad_FreshInstall(t *testing.T) {
+	dir, _ := tempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("cfg = %+v, want the defaults", cfg)
+	}
+	if _, err := os.Stat(filepath.Join(dir, configFileName)); !os.IsNotExist(err) {
+		t.Errorf("config.json should only be written on save, got %v", err)
+	}
+}
+
+func TestLoad_MissingFieldsKeepDefaults(t *testing.T) {
+	dir, _ := tempHome(t)
+	writeFile(t, filepath.Join(dir, configFileName), `{"version": 1, "last_plugin": "hn", "include_numbers": false}`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.LastPlugin = "hn"
+	want.IncludeNumbers = false
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("cfg = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestLoad_MigratesLegacy(t *testing.T) {
+	dir, home := tempHome(t)
+	legacy := filepath.Join(home, legacyFileName)
+	writeFile(t, legacy, `{
+		"last_plugin": "quotes",
+		"include_punctuation": false,
+		"metrics": {"a": {"attempts": 3, "mistakes": 1}},
+		"history": [{"wpm": 42, "accuracy": 99, "timestamp": 1}]
+	}`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Default()
+	want.LastPlugin = "quotes"
+	want.IncludePunctuation = false
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("cfg = %+v, want %+v", cfg, want)
+	}
+
+	// The settings are rewritten to config.json without the run data, which
+	// the store migrates on its own
+	data, err := os.ReadFile(filepath.Join(dir, configFileName))
+	if err != nil {
+		t.Fatalf("migrated settings were not saved: %v", err)
+	}
+	if strings.Contains(string(data), "metrics") || strings.Contains(string(data), "history") {
+		t.Errorf("config.json should only hold settings:\n%s", data)
+	}
+	var saved Config
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != Version || saved.LastPlugin != "quotes" {
+		t.Errorf("saved = %+v, want the migrated settings at version %d", saved, Version)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Errorf("the legacy file should be left in place: %v", err)
+	}
+
+	// Once migrated, the legacy file is no longer read
+	writeFile(t, legacy, `{"last_plugin": "hn"}`)
+	if cfg, err := Load(); err != nil || cfg.LastPlugin != "quotes" {
+		t.Errorf("Load() = %+v, %v, want the settings from config.json", cfg, err)
+	}
+}
+
+func TestLoad_ParseErrors(t *testing.T) {
+	t.Run("Config", func(t *testing.T) {
+		dir, home := tempHome(t)
+		path := filepath.Join(dir, configFileName)
+		writeFile(t, path, `{"last_plugin": `)
+		writeFile(t, filepath.Join(home, legacyFileName), `{"last_plugin": "quotes"}`)
+
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("err = %v, want it to name %s", err, path)
+		}
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		dir, home := tempHome(t)
+		path := filepath.Join(home, legacyFileName)
+		writeFile(t, path, `not json`)
+
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("err = %v, want it to name %s", err, path)
+		}
+		if _, err := os.Stat(filepath.Join(dir, configFileName)); !os.IsNotExist(err) {
+			t.Errorf("a broken legacy file should not be migrated, got %v", err)
+		}
+	})
+}
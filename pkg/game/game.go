@@ -5,9 +5,22 @@ import (
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
-// TypingTest represents the state of a typing session
+// TimeLimits are the durations, in seconds, offered for timed tests
+var TimeLimits = []int{15, 30, 60, 120}
+
+// WordCounts are the lengths offered for word-count tests
+var WordCounts = []int{10, 25, 50, 100}
+
+// TabWidths are the tab sizes offered for code
+var TabWidths = []int{2, 4, 8}
+
+// TypingTest represents the state of a typing session.
+// All positions (InitialMistake keys, Position, Length) are rune indices, not byte offsets.
 type TypingTest struct {
 	TargetText     string
 	UserInput      string
@@ -17,21 +30,117 @@ type TypingTest struct {
 	IsStarted      bool
 	Errors         int
 	CorrectChars   int
-	InitialMistake map[int]bool // Tracks indices where the first attempt was incorrect
+	InitialMistake map[int]bool // Tracks rune indices where the first attempt was incorrect
+	Keystrokes     []config.Keystroke
+	TimeLimit      time.Duration // Zero for untimed tests
+	Code           bool          // Target is source code and keeps its line structure
+	AutoIndent     bool          // Indentation after a typed newline is filled in
+
+	now func() time.Time // Clock used for timestamps; replays substitute a recorded one
 }
 
-// NewTypingTest creates a new typing test with the given target text
+// NewTypingTest creates a new typing test with the given target text.
+// The text is normalized to NFC so that decomposed accents ("e" + U+0301)
+// match the precomposed runes a keyboard sends.
 func NewTypingTest(text string) *TypingTest {
 	return &TypingTest{
-		TargetText:     text,
+		TargetText:     norm.NFC.String(text),
 		InitialMistake: make(map[int]bool),
+		now:            time.Now,
+	}
+}
+
+// clock returns the current time according to the test's clock
+func (t *TypingTest) clock() time.Time {
+	if t.now == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+// record appends an event to the keystroke log
+func (t *TypingTest) record(kind string, text string) {
+	t.Keystrokes = append(t.Keystrokes, config.Keystroke{
+		Kind: kind,
+		Rune: text,
+		At:   t.clock().Sub(t.StartTime),
+	})
+}
+
+// TargetRunes returns the target text as runes
+func (t *TypingTest) TargetRunes() []rune {
+	return []rune(t.TargetText)
+}
+
+// InputRunes returns the user input as runes
+func (t *TypingTest) InputRunes() []rune {
+	return []rune(t.UserInput)
+}
+
+// Position returns the number of runes typed so far
+func (t *TypingTest) Position() int {
+	return utf8.RuneCountInString(t.UserInput)
+}
+
+// Length returns the number of runes in the target text
+func (t *TypingTest) Length() int {
+	return utf8.RuneCountInString(t.TargetText)
+}
+
+// IsFinished reports whether the user has typed at least as many runes as the target
+func (t *TypingTest) IsFinished() bool {
+	return t.Position() >= t.Length()
+}
+
+// IsTimed reports whether the test ends on a countdown rather than at the end of the text
+func (t *TypingTest) IsTimed() bool {
+	return t.TimeLimit > 0
+}
+
+// Remaining returns the time left in a timed test
+func (t *TypingTest) Remaining() time.Duration {
+	if !t.IsTimed() {
+		return 0
+	}
+	if !t.IsStarted {
+		return t.TimeLimit
+	}
+	remaining := t.TimeLimit - t.clock().Sub(t.StartTime)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// CheckTime completes a timed test whose countdown has run out and reports whether it did
+func (t *TypingTest) CheckTime() bool {
+	if !t.IsTimed() || !t.IsStarted || t.IsComplete || t.Remaining() > 0 {
+		return false
 	}
+	t.Complete()
+	return true
+}
+
+// AppendText extends the target text, used to stream more content into a timed test
+func (t *TypingTest) AppendText(text string) {
+	text = norm.NFC.String(text)
+	if text == "" {
+		return
+	}
+	if t.TargetText != "" {
+		if t.Code {
+			t.TargetText += "\n"
+		} else {
+			t.TargetText += " "
+		}
+	}
+	t.TargetText += text
 }
 
 // Start begins the typing test if not already started
 func (t *TypingTest) Start() {
 	if !t.IsStarted {
-		t.StartTime = time.Now()
+		t.StartTime = t.clock()
 		t.IsStarted = true
 	}
 }
@@ -43,10 +152,17 @@ func (t *TypingTest) AddInput(r rune) {
 	}
 	t.Start()
 
-	index := len(t.UserInput)
+	target := t.TargetRunes()
+	index := t.Position()
+
+	// A timed test waits for more content rather than running past the buffer
+	if t.IsTimed() && index >= len(target) {
+		return
+	}
+
 	// Track initial mistake if this is the first attempt at this index
-	if _, attempted := t.InitialMistake[index]; !attempted && index < len(t.TargetText) {
-		if byte(r) != t.TargetText[index] {
+	if _, attempted := t.InitialMistake[index]; !attempted && index < len(target) {
+		if r != target[index] {
 			t.InitialMistake[index] = true
 		} else {
 			// Mark as attempted but correct (false)
@@ -55,19 +171,57 @@ func (t *TypingTest) AddInput(r rune) {
 	}
 
 	t.UserInput += string(r)
+	t.record(config.KeystrokeInsert, string(r))
+
+	if r == '\n' && t.AutoIndent && index < len(target) && target[index] == '\n' {
+		t.indent()
+	}
 
 	// Check for completion
-	if len(t.UserInput) >= len(t.TargetText) {
+	if !t.IsTimed() && t.IsFinished() {
 		t.Complete()
 	}
 }
 
+// indent fills in the whitespace at the cursor, so only the code on each
+// line has to be typed
+func (t *TypingTest) indent() {
+	target := t.TargetRunes()
+	end := t.Position()
+	for end < len(target) && (target[end] == ' ' || target[end] == '\t') {
+		end++
+	}
+	if whitespace := string(target[t.Position():end]); whitespace != "" {
+		t.UserInput += whitespace
+		t.record(config.KeystrokeIndent, whitespace)
+	}
+}
+
+// Column returns the rune offset of the cursor within its line
+func (t *TypingTest) Column() int {
+	line := t.UserInput[strings.LastIndexByte(t.UserInput, '\n')+1:]
+	return utf8.RuneCountInString(line)
+}
+
+// TypeTab types spaces up to the next tab stop, as tabs in code are expanded
+// to spaces
+func (t *TypingTest) TypeTab(width int) {
+	if width <= 0 {
+		return
+	}
+	for n := width - t.Column()%width; n > 0 && !t.IsComplete; n-- {
+		t.AddInput(' ')
+	}
+}
+
 // Backspace removes the last character from user input
 func (t *TypingTest) Backspace() {
 	if t.IsComplete || len(t.UserInput) == 0 {
 		return
 	}
-	t.UserInput = t.UserInput[:len(t.UserInput)-1]
+	_, size := utf8.DecodeLastRuneInString(t.UserInput)
+	t.UserInput = t.UserInput[:len(t.UserInput)-size]
+	t.record(config.KeystrokeBackspace, "")
 }
 
 // BackspaceWord removes the last word from user input
@@ -82,26 +236,31 @@ func (t *TypingTest) BackspaceWord() {
 		return
 	}
 
-	// 1. Remove trailing spaces
-	for len(runes) > 0 && runes[len(runes)-1] == ' ' {
+	// 1. Remove trailing spaces and line breaks
+	for len(runes) > 0 && (runes[len(runes)-1] == ' ' || runes[len(runes)-1] == '\n') {
 		runes = runes[:len(runes)-1]
 	}
 
 	// 2. Remove characters until space or start
-	for len(runes) > 0 && runes[len(runes)-1] != ' ' {
+	for len(runes) > 0 && runes[len(runes)-1] != ' ' && runes[len(runes)-1] != '\n' {
 		runes = runes[:len(runes)-1]
 	}
 
 	t.UserInput = string(runes)
+	t.record(config.KeystrokeDeleteWord, "")
 }
 
 // Complete finishes the test and calculates final stats
 func (t *TypingTest) Complete() {
-	if !t.IsStarted {
+	if !t.IsStarted || t.IsComplete {
 		return
 	}
-	t.EndTime = time.Now()
+	t.EndTime = t.clock()
+	if t.IsTimed() && t.EndTime.Sub(t.StartTime) > t.TimeLimit {
+		t.EndTime = t.StartTime.Add(t.TimeLimit)
+	}
 	t.IsComplete = true
+	t.record(config.KeystrokeFinish, "")
 	t.CalculateStats()
 }
 
@@ -110,9 +269,12 @@ func (t *TypingTest) CalculateStats() {
 	t.CorrectChars = 0
 	t.Errors = 0
 
-	for i, char := range t.TargetText {
-		if i < len(t.UserInput) {
-			if t.UserInput[i] == byte(char) {
+	target := t.TargetRunes()
+	input := t.InputRunes()
+
+	for i, char := range target {
+		if i < len(input) {
+			if input[i] == char {
 				t.CorrectChars++
 			} else {
 				t.Errors++
@@ -124,28 +286,30 @@ func (t *TypingTest) CalculateStats() {
 	}
 
 	// key point: errors should also account for extra characters typed if any (though we capped it above)
-	if len(t.UserInput) > len(t.TargetText) {
-		t.Errors += len(t.UserInput) - len(t.TargetText)
+	if len(input) > len(target) {
+		t.Errors += len(input) - len(target)
 	}
 }
 
-// WPM calculates words per minute
-func (t *TypingTest) WPM() float64 {
-	var duration time.Duration
+// Elapsed returns how long the test has been running, or how long it took once complete
+func (t *TypingTest) Elapsed() time.Duration {
 	if t.IsComplete {
-		duration = t.EndTime.Sub(t.StartTime)
+		return t.EndTime.Sub(t.StartTime)
 	} else if t.IsStarted {
-		duration = time.Since(t.StartTime)
-	} else {
-		return 0
+		return t.clock().Sub(t.StartTime)
 	}
+	return 0
+}
 
+// WPM calculates words per minute
+func (t *TypingTest) WPM() float64 {
+	duration := t.Elapsed()
 	if duration.Minutes() == 0 {
 		return 0
 	}
 
 	// Standard WPM calculation: (characters / 5) / minutes
-	return (float64(len(t.UserInput)) / 5.0) / duration.Minutes()
+	return (float64(t.Position()) / 5.0) / duration.Minutes()
 }
 
 // Accuracy calculates the percentage of characters correct on the first try
@@ -177,12 +341,13 @@ func (t *TypingTest) Accuracy() float64 {
 // GetSessionStats calculates character-level metrics for the current session
 func (t *TypingTest) GetSessionStats() map[string]struct{ Attempts, Mistakes int } {
 	stats := make(map[string]struct{ Attempts, Mistakes int })
+	target := t.TargetRunes()
 
 	for i, mistyped := range t.InitialMistake {
-		if i >= len(t.TargetText) {
+		if i >= len(target) {
 			continue
 		}
-		char := string(t.TargetText[i])
+		char := string(target[i])
 		s := stats[char]
 		s.Attempts++
 		if mistyped {
@@ -193,6 +358,125 @@ func (t *TypingTest) GetSessionStats() map[string]struct{ Attempts, Mistakes int
 	return stats
 }
 
+// Replay rebuilds a session from its keystroke log as it stood at the given
+// offset from the start. Events are applied through the same methods used
+// while typing, so the result can be rendered exactly like a live test.
+func Replay(text string, log []config.Keystroke, at time.Duration) *TypingTest {
+	r := newReplayer(text, log)
+	r.advance(at)
+	return r.test
+}
+
+// Playback replays a keystroke log as time moves on. Each call carries on
+// from the last, so the log is only applied from the start again to go back.
+type Playback struct {
+	text string
+	log  []config.Keystroke
+	r    *replayer
+}
+
+// NewPlayback returns a playback of a run that typed text with log
+func NewPlayback(text string, log []config.Keystroke) *Playback {
+	return &Playback{text: text, log: log, r: newReplayer(text, log)}
+}
+
+// At returns the test as it stood at the given offset from the start
+func (p *Playback) At(at time.Duration) *TypingTest {
+	if at < p.r.at {
+		p.r = newReplayer(p.text, p.log)
+	}
+	p.r.advance(at)
+	return p.r.test
+}
+
+// replayer applies a keystroke log to a test in order, so that a replay can
+// be moved forward a little at a time without starting over
+type replayer struct {
+	test   *TypingTest
+	log    []config.Keystroke
+	next   int           // Index of the next keystroke to apply
+	at     time.Duration // Offset the test was last advanced to
+	offset time.Duration // Offset the test's clock reads
+}
+
+func newReplayer(text string, log []config.Keystroke) *replayer {
+	r := &replayer{test: NewTypingTest(text), log: log}
+	start := time.Now()
+	r.test.now = func() time.Time { return start.Add(r.offset) }
+	r.test.Start()
+	return r
+}
+
+// advance applies the keystrokes made by at, which must not be earlier than
+// the last offset advanced to
+func (r *replayer) advance(at time.Duration) {
+	for ; r.next < len(r.log); r.next++ {
+		k := r.log[r.next]
+		if k.At > at || r.test.IsComplete {
+			break
+		}
+		r.offset = k.At
+		r.test.apply(k)
+	}
+
+	if !r.test.IsComplete {
+		r.offset = at
+	}
+	r.at = at
+}
+
+// CountWords returns the number of whitespace separated words in text
+func CountWords(text string) int {
+	return len(strings.Fields(text))
+}
+
+// TrimWords joins the first n words of text with single spaces
+func TrimWords(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) > n {
+		words = words[:n]
+	}
+	return strings.Join(words, " ")
+}
+
+// CutWords returns text up to the end of its nth word. Unlike TrimWords the
+// whitespace between words is kept, so code keeps its layout.
+func CutWords(text string, n int) string {
+	words, inWord := 0, false
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if inWord && words == n {
+				return text[:i]
+			}
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return strings.TrimRightFunc(text, unicode.IsSpace)
+}
+
+// apply performs a logged keystroke
+func (t *TypingTest) apply(k config.Keystroke) {
+	switch k.Kind {
+	case config.KeystrokeInsert:
+		for _, r := range k.Rune {
+			t.AddInput(r)
+		}
+	case config.KeystrokeBackspace:
+		t.Backspace()
+	case config.KeystrokeDeleteWord:
+		t.BackspaceWord()
+	case config.KeystrokeIndent:
+		if !t.IsComplete {
+			t.UserInput += k.Rune
+		}
+	case config.KeystrokeFinish:
+		t.Complete()
+	}
+}
+
 // ApplyFilters processes the input text based on the configuration
 func ApplyFilters(text string, cfg *config.Config) string {
 	var sb strings.Builder
@@ -230,3 +514,49 @@ func ApplyFilters(text string, cfg *config.Config) string {
 
 	return result
 }
+
+// ApplyCodeFilters prepares source code for a code test. Line breaks and
+// indentation are kept, with tabs expanded to tabWidth columns, while
+// trailing whitespace and runs of blank lines are dropped. Character filters
+// are not applied, as code has to be typed exactly as written.
+func ApplyCodeFilters(text string, tabWidth int) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRightFunc(ExpandTabs(line, tabWidth), unicode.IsSpace)
+		if line == "" {
+			blank = true
+			continue
+		}
+		if blank && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		blank = false
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExpandTabs replaces the tabs in a line with spaces up to the next multiple
+// of width columns
+func ExpandTabs(line string, width int) string {
+	if width <= 0 || !strings.ContainsRune(line, '\t') {
+		return line
+	}
+	var sb strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := width - col%width
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteRune(r)
+		col++
+	}
+	return sb.String()
+}
@@ -3,6 +3,7 @@ package game
 import (
 	"go-racer/pkg/config"
 	"testing"
+	"time"
 )
 
 func TestTypingTest_Metrics(t *testing.T) {
@@ -123,3 +124,432 @@ func TestApplyFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestTypingTest_Unicode(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		input        string
+		wantComplete bool
+		wantCorrect  int
+		wantErrors   int
+		wantAccuracy float64
+	}{
+		{
+			name:         "Spanish accents and tilde",
+			target:       "El niño comió piña",
+			input:        "El niño comió piña",
+			wantComplete: true,
+			wantCorrect:  18,
+			wantAccuracy: 100,
+		},
+		{
+			name:         "Spanish unaccented typo",
+			target:       "año",
+			input:        "ano",
+			wantComplete: true,
+			wantCorrect:  2,
+			wantErrors:   1,
+			wantAccuracy: 100 * 2.0 / 3.0,
+		},
+		{
+			name:         "German umlauts and eszett",
+			target:       "Größe über",
+			input:        "Größe über",
+			wantComplete: true,
+			wantCorrect:  10,
+			wantAccuracy: 100,
+		},
+		{
+			name:         "German partial input",
+			target:       "Straße",
+			input:        "Stra",
+			wantComplete: false,
+			wantCorrect:  4,
+			wantAccuracy: 100,
+		},
+		{
+			name:         "Emoji with skin tone modifier",
+			target:       "ok 👍🏽",
+			input:        "ok 👍🏽",
+			wantComplete: true,
+			wantCorrect:  5,
+			wantAccuracy: 100,
+		},
+		{
+			name:         "Emoji mistyped",
+			target:       "🚀 go",
+			input:        "x go",
+			wantComplete: true,
+			wantCorrect:  3,
+			wantErrors:   1,
+			wantAccuracy: 75,
+		},
+		{
+			name:         "Combining mark in target matches precomposed input",
+			target:       "cafe\u0301",
+			input:        "caf\u00e9",
+			wantComplete: true,
+			wantCorrect:  4,
+			wantAccuracy: 100,
+		},
+		{
+			name:         "Combining mark without precomposed form",
+			target:       "q\u0303",
+			input:        "q\u0303",
+			wantComplete: true,
+			wantCorrect:  2,
+			wantAccuracy: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewTypingTest(tt.target)
+			for _, r := range tt.input {
+				game.AddInput(r)
+			}
+			game.CalculateStats()
+
+			if game.IsComplete != tt.wantComplete {
+				t.Errorf("IsComplete = %v, want %v", game.IsComplete, tt.wantComplete)
+			}
+			if game.CorrectChars != tt.wantCorrect {
+				t.Errorf("CorrectChars = %d, want %d", game.CorrectChars, tt.wantCorrect)
+			}
+			if game.Errors != tt.wantErrors {
+				t.Errorf("Errors = %d, want %d", game.Errors, tt.wantErrors)
+			}
+			if got := game.Accuracy(); got < tt.wantAccuracy-0.01 || got > tt.wantAccuracy+0.01 {
+				t.Errorf("Accuracy() = %.2f, want %.2f", got, tt.wantAccuracy)
+			}
+		})
+	}
+}
+
+func TestTypingTest_UnicodeSessionStats(t *testing.T) {
+	game := NewTypingTest("ñandú")
+	for _, r := range "nandú" {
+		game.AddInput(r)
+	}
+
+	stats := game.GetSessionStats()
+	if s := stats["ñ"]; s.Attempts != 1 || s.Mistakes != 1 {
+		t.Errorf("Stats for ñ incorrect: %+v", s)
+	}
+	if s := stats["ú"]; s.Attempts != 1 || s.Mistakes != 0 {
+		t.Errorf("Stats for ú incorrect: %+v", s)
+	}
+	if _, ok := stats["\xc3"]; ok {
+		t.Error("Stats should be keyed by rune, not byte")
+	}
+}
+
+func TestTypingTest_UnicodeBackspace(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		input  string
+		want   string
+	}{
+		{name: "Spanish", target: "señor", input: "señ", want: "se"},
+		{name: "German", target: "Grüße", input: "Grü", want: "Gr"},
+		{name: "Emoji", target: "hi 🎉!", input: "hi 🎉", want: "hi "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := NewTypingTest(tt.target)
+			for _, r := range tt.input {
+				game.AddInput(r)
+			}
+			game.Backspace()
+			if game.UserInput != tt.want {
+				t.Errorf("UserInput = %q, want %q", game.UserInput, tt.want)
+			}
+			if game.Position() != len([]rune(tt.want)) {
+				t.Errorf("Position() = %d, want %d", game.Position(), len([]rune(tt.want)))
+			}
+		})
+	}
+}
+
+func TestTypingTest_KeystrokeLog(t *testing.T) {
+	game := NewTypingTest("ab cd")
+	for _, r := range "ab x" {
+		game.AddInput(r)
+	}
+	game.Backspace()
+	game.BackspaceWord()
+	game.Complete()
+
+	wantKinds := []string{
+		config.KeystrokeInsert,
+		config.KeystrokeInsert,
+		config.KeystrokeInsert,
+		config.KeystrokeInsert,
+		config.KeystrokeBackspace,
+		config.KeystrokeDeleteWord,
+		config.KeystrokeFinish,
+	}
+	if len(game.Keystrokes) != len(wantKinds) {
+		t.Fatalf("got %d keystrokes, want %d: %+v", len(game.Keystrokes), len(wantKinds), game.Keystrokes)
+	}
+	for i, k := range game.Keystrokes {
+		if k.Kind != wantKinds[i] {
+			t.Errorf("keystroke %d kind = %q, want %q", i, k.Kind, wantKinds[i])
+		}
+		if i > 0 && k.At < game.Keystrokes[i-1].At {
+			t.Errorf("keystroke %d is earlier than the one before it", i)
+		}
+	}
+	if game.Keystrokes[3].Rune != "x" {
+		t.Errorf("keystroke 3 rune = %q, want %q", game.Keystrokes[3].Rune, "x")
+	}
+
+	// Completing twice must not log a second finish
+	game.Complete()
+	if len(game.Keystrokes) != len(wantKinds) {
+		t.Errorf("Complete() on a finished test added keystrokes")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	log := []config.Keystroke{
+		{Kind: config.KeystrokeInsert, Rune: "h", At: 100 * time.Millisecond},
+		{Kind: config.KeystrokeInsert, Rune: "x", At: 200 * time.Millisecond},
+		{Kind: config.KeystrokeBackspace, At: 300 * time.Millisecond},
+		{Kind: config.KeystrokeInsert, Rune: "i", At: 400 * time.Millisecond},
+		{Kind: config.KeystrokeInsert, Rune: "!", At: 500 * time.Millisecond},
+		{Kind: config.KeystrokeFinish, At: 500 * time.Millisecond},
+	}
+
+	tests := []struct {
+		name         string
+		at           time.Duration
+		wantInput    string
+		wantComplete bool
+	}{
+		{name: "Before first key", at: 50 * time.Millisecond, wantInput: ""},
+		{name: "After mistake", at: 250 * time.Millisecond, wantInput: "hx"},
+		{name: "After backspace", at: 300 * time.Millisecond, wantInput: "h"},
+		{name: "Finished", at: time.Second, wantInput: "hi!", wantComplete: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			game := Replay("hi!", log, tt.at)
+			if game.UserInput != tt.wantInput {
+				t.Errorf("UserInput = %q, want %q", game.UserInput, tt.wantInput)
+			}
+			if game.IsComplete != tt.wantComplete {
+				t.Errorf("IsComplete = %v, want %v", game.IsComplete, tt.wantComplete)
+			}
+		})
+	}
+
+	// The replayed test keeps the first-try mistake and the original timing
+	game := Replay("hi!", log, time.Second)
+	if !game.InitialMistake[1] {
+		t.Error("expected index 1 to be recorded as an initial mistake")
+	}
+	if got := game.EndTime.Sub(game.StartTime); got != 500*time.Millisecond {
+		t.Errorf("replayed duration = %v, want 500ms", got)
+	}
+	if len(game.Keystrokes) != len(log) {
+		t.Errorf("replayed log has %d keystrokes, want %d", len(game.Keystrokes), len(log))
+	}
+}
+
+func TestTypingTest_Timed(t *testing.T) {
+	now := time.Now()
+	game := NewTypingTest("ab")
+	game.now = func() time.Time { return now }
+	game.TimeLimit = 15 * time.Second
+
+	if game.Remaining() != 15*time.Second {
+		t.Errorf("Remaining() before start = %v, want 15s", game.Remaining())
+	}
+
+	game.AddInput('a')
+	game.AddInput('b')
+	if game.IsComplete {
+		t.Fatal("timed test should not complete at the end of the text")
+	}
+
+	// Input past the end of the buffer is ignored until more text arrives
+	game.AddInput(' ')
+	if game.UserInput != "ab" {
+		t.Errorf("UserInput = %q, want %q", game.UserInput, "ab")
+	}
+
+	game.AppendText("cd")
+	if game.TargetText != "ab cd" {
+		t.Errorf("TargetText = %q, want %q", game.TargetText, "ab cd")
+	}
+	game.AddInput(' ')
+	game.AddInput('c')
+
+	now = now.Add(10 * time.Second)
+	if game.CheckTime() {
+		t.Fatal("CheckTime() ended the test early")
+	}
+	if game.Remaining() != 5*time.Second {
+		t.Errorf("Remaining() = %v, want 5s", game.Remaining())
+	}
+
+	now = now.Add(7 * time.Second)
+	if !game.CheckTime() || !game.IsComplete {
+		t.Fatal("CheckTime() should end the test once time is up")
+	}
+	if got := game.EndTime.Sub(game.StartTime); got != 15*time.Second {
+		t.Errorf("duration = %v, want it capped at 15s", got)
+	}
+	if got, want := game.WPM(), (4.0/5.0)/0.25; got != want {
+		t.Errorf("WPM() = %.2f, want %.2f", got, want)
+	}
+}
+
+func TestTrimWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		n     int
+		want  string
+	}{
+		{name: "Exact", input: "one two three", n: 3, want: "one two three"},
+		{name: "Trimmed", input: "one two three four", n: 2, want: "one two"},
+		{name: "Short", input: "one", n: 5, want: "one"},
+		{name: "Collapses spaces", input: " one   two  ", n: 2, want: "one two"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TrimWords(tt.input, tt.n); got != tt.want {
+				t.Errorf("TrimWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCodeFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		tabWidth int
+		want     string
+	}{
+		{name: "Keeps lines", input: "func f() {\n    return\n}", tabWidth: 4, want: "func f() {\n    return\n}"},
+		{name: "Expands tabs", input: "if x {\n\ty()\n}", tabWidth: 4, want: "if x {\n    y()\n}"},
+		{name: "Tab stops", input: "a\tb\n\t\tc", tabWidth: 2, want: "a b\n    c"},
+		{name: "Trailing whitespace", input: "x := 1   \r\ny := 2\t", tabWidth: 4, want: "x := 1\ny := 2"},
+		{name: "Blank lines", input: "\n\na()\n\n\n\nb()\n\n", tabWidth: 4, want: "a()\n\nb()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyCodeFilters(tt.input, tt.tabWidth); got != tt.want {
+				t.Errorf("ApplyCodeFilters() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypingTest_Code(t *testing.T) {
+	game := NewTypingTest("if x {\n    y()\n}")
+	game.Code = true
+
+	for _, r := range "if x {\n" {
+		game.AddInput(r)
+	}
+	game.TypeTab(4)
+	if game.Column() != 4 {
+		t.Fatalf("Column() = %d after a tab, want 4", game.Column())
+	}
+	for _, r := range "y()\n}" {
+		game.AddInput(r)
+	}
+
+	if !game.IsComplete {
+		t.Fatal("expected the test to be complete")
+	}
+	if game.Accuracy() != 100 {
+		t.Errorf("Accuracy() = %.1f, want 100", game.Accuracy())
+	}
+}
+
+func TestTypingTest_AutoIndent(t *testing.T) {
+	target := "if x {\n    y()\n}"
+	game := NewTypingTest(target)
+	game.Code = true
+	game.AutoIndent = true
+
+	for _, r := range "if x {\n" {
+		game.AddInput(r)
+	}
+	if game.UserInput != "if x {\n    " {
+		t.Fatalf("UserInput = %q, want the indentation filled in", game.UserInput)
+	}
+	for _, r := range "y()\n}" {
+		game.AddInput(r)
+	}
+	if !game.IsComplete || game.UserInput != target {
+		t.Fatalf("UserInput = %q (complete %v), want the whole target", game.UserInput, game.IsComplete)
+	}
+
+	// Replays rebuild the same input without auto-indent switched on
+	replayed := Replay(target, game.Keystrokes, game.Elapsed())
+	if replayed.UserInput != target {
+		t.Errorf("replayed UserInput = %q, want %q", replayed.UserInput, target)
+	}
+
+	// A newline typed in the wrong place is not followed by indentation
+	wrong := NewTypingTest("a\n  b")
+	wrong.Code = true
+	wrong.AutoIndent = true
+	wrong.AddInput('\n')
+	if wrong.UserInput != "\n" {
+		t.Errorf("UserInput = %q, want no indentation after a mistake", wrong.UserInput)
+	}
+}
+
+func TestTypingTest_BackspaceWordAcrossLines(t *testing.T) {
+	game := NewTypingTest("a()\n    b()")
+	game.Code = true
+	for _, r := range "a()\n    " {
+		game.AddInput(r)
+	}
+	game.BackspaceWord()
+	if game.UserInput != "" {
+		t.Errorf("UserInput = %q, want the line break and word removed", game.UserInput)
+	}
+}
+
+func TestTypingTest_AppendCode(t *testing.T) {
+	game := NewTypingTest("a()")
+	game.Code = true
+	game.AppendText("b()")
+	if game.TargetText != "a()\nb()" {
+		t.Errorf("TargetText = %q, want code joined by a line break", game.TargetText)
+	}
+}
+
+func TestCutWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		n     int
+		want  string
+	}{
+		{name: "Keeps layout", input: "if x {\n    y()\n}", n: 4, want: "if x {\n    y()"},
+		{name: "Short", input: "a()\n", n: 5, want: "a()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CutWords(tt.input, tt.n); got != tt.want {
+				t.Errorf("CutWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
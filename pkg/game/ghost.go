@@ -0,0 +1,82 @@
+package game
+
+import (
+	"math"
+	"time"
+	"unicode/utf8"
+
+	"go-racer/pkg/config"
+)
+
+// GhostPaces are the target speeds, in WPM, offered for pace ghosts
+var GhostPaces = []int{30, 40, 50, 60, 70, 80, 100, 120}
+
+// Ghost is an opponent that moves through the text at a known pace, either by
+// replaying a recorded run on the same text or at a fixed WPM
+type Ghost struct {
+	Text       string
+	Keystrokes []config.Keystroke // Replayed when set
+	WPM        float64            // Pace used when there is no recording
+
+	replay *Playback // The recording as far as it has been played
+}
+
+// NewRecordedGhost replays a run that typed text with the given keystrokes
+func NewRecordedGhost(text string, keystrokes []config.Keystroke, wpm float64) *Ghost {
+	return &Ghost{Text: text, Keystrokes: keystrokes, WPM: wpm, replay: NewPlayback(text, keystrokes)}
+}
+
+// IsRecorded reports whether the ghost replays an earlier run
+func (g *Ghost) IsRecorded() bool {
+	return len(g.Keystrokes) > 0
+}
+
+// Position returns how many runes the ghost has typed after elapsed. A
+// recording carries on from the last call, so it is only replayed from the
+// start when asked about an earlier time.
+func (g *Ghost) Position(elapsed time.Duration) int {
+	if g.IsRecorded() {
+		if g.replay == nil {
+			g.replay = NewPlayback(g.Text, g.Keystrokes)
+		}
+		return g.replay.At(elapsed).Position()
+	}
+
+	pos := int(g.WPM * 5 * elapsed.Minutes())
+	if length := utf8.RuneCountInString(g.Text); pos > length {
+		return length
+	}
+	return pos
+}
+
+// TimeToReach returns how long the ghost takes to type pos runes, and false if it never gets there
+func (g *Ghost) TimeToReach(pos int) (time.Duration, bool) {
+	if g.IsRecorded() {
+		t := NewTypingTest(g.Text)
+		for _, k := range g.Keystrokes {
+			t.apply(k)
+			if t.Position() >= pos {
+				return k.At, true
+			}
+		}
+		return 0, false
+	}
+
+	if g.WPM <= 0 || pos > utf8.RuneCountInString(g.Text) {
+		return 0, false
+	}
+	return time.Duration(math.Round(float64(pos) / 5 / g.WPM * float64(time.Minute))), true
+}
+
+// Gap compares a test against the ghost. Chars is how many runes the ghost was
+// ahead when the test ended, and Seconds how much sooner it reached the same
+// point; both are negative when the typist was ahead.
+func (g *Ghost) Gap(t *TypingTest) (chars int, seconds float64) {
+	elapsed := t.Elapsed()
+	chars = g.Position(elapsed) - t.Position()
+
+	if at, ok := g.TimeToReach(t.Position()); ok {
+		seconds = (elapsed - at).Seconds()
+	}
+	return chars, seconds
+}
@@ -0,0 +1,113 @@
+package game
+
+import (
+	"go-racer/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestGhost_Pace(t *testing.T) {
+	// 60 WPM is 300 runes a minute, or 5 a second
+	ghost := &Ghost{Text: "the quick brown fox", WPM: 60}
+
+	if got := ghost.Position(2 * time.Second); got != 10 {
+		t.Errorf("Position(2s) = %d, want 10", got)
+	}
+	if got := ghost.Position(time.Minute); got != 19 {
+		t.Errorf("Position(1m) = %d, want it capped at the text length", got)
+	}
+	if got, ok := ghost.TimeToReach(19); !ok || got != 3800*time.Millisecond {
+		t.Errorf("TimeToReach(19) = %v, %v, want 3.8s", got, ok)
+	}
+}
+
+func TestGhost_Recorded(t *testing.T) {
+	ghost := &Ghost{
+		Text: "abc",
+		Keystrokes: []config.Keystroke{
+			{Kind: config.KeystrokeInsert, Rune: "a", At: 1 * time.Second},
+			{Kind: config.KeystrokeInsert, Rune: "x", At: 2 * time.Second},
+			{Kind: config.KeystrokeBackspace, At: 3 * time.Second},
+			{Kind: config.KeystrokeInsert, Rune: "b", At: 4 * time.Second},
+			{Kind: config.KeystrokeInsert, Rune: "c", At: 5 * time.Second},
+			{Kind: config.KeystrokeFinish, At: 5 * time.Second},
+		},
+	}
+
+	positions := map[time.Duration]int{
+		0:                       0,
+		1500 * time.Millisecond: 1,
+		2 * time.Second:         2,
+		3 * time.Second:         1,
+		10 * time.Second:        3,
+	}
+	for at, want := range positions {
+		if got := ghost.Position(at); got != want {
+			t.Errorf("Position(%v) = %d, want %d", at, got, want)
+		}
+	}
+
+	if got, ok := ghost.TimeToReach(3); !ok || got != 5*time.Second {
+		t.Errorf("TimeToReach(3) = %v, %v, want 5s", got, ok)
+	}
+}
+
+func TestGhost_RecordedStepByStep(t *testing.T) {
+	log := []config.Keystroke{
+		{Kind: config.KeystrokeInsert, Rune: "a", At: 100 * time.Millisecond},
+		{Kind: config.KeystrokeInsert, Rune: "x", At: 250 * time.Millisecond},
+		{Kind: config.KeystrokeBackspace, At: 300 * time.Millisecond},
+		{Kind: config.KeystrokeInsert, Rune: "b", At: 450 * time.Millisecond},
+		{Kind: config.KeystrokeInsert, Rune: "c", At: 600 * time.Millisecond},
+		{Kind: config.KeystrokeFinish, At: 600 * time.Millisecond},
+	}
+	ghost := NewRecordedGhost("abc", log, 60)
+	replay := ghost.replay.r
+
+	// Ticks only apply the keystrokes made since the last one
+	for at := time.Duration(0); at <= time.Second; at += 50 * time.Millisecond {
+		if got, want := ghost.Position(at), Replay("abc", log, at).Position(); got != want {
+			t.Errorf("Position(%v) = %d, want %d", at, got, want)
+		}
+	}
+	if ghost.replay.r != replay {
+		t.Error("the replay was started over while moving forward")
+	}
+
+	// Going back in time starts the replay over
+	if got := ghost.Position(260 * time.Millisecond); got != 2 {
+		t.Errorf("Position(260ms) = %d, want 2", got)
+	}
+}
+
+func TestGhost_Gap(t *testing.T) {
+	now := time.Now()
+	test := NewTypingTest("abcdefghij")
+	test.now = func() time.Time { return now }
+	test.Start()
+
+	// The typist manages 5 runes in 2 seconds before giving up
+	for _, r := range "abcde" {
+		test.AddInput(r)
+	}
+	now = now.Add(2 * time.Second)
+	test.Complete()
+
+	// A 60 WPM ghost types 5 runes a second, so it reached 5 runes after 1s
+	// and was 5 runes further along when the typist stopped
+	ghost := &Ghost{Text: test.TargetText, WPM: 60}
+	chars, seconds := ghost.Gap(test)
+	if chars != 5 {
+		t.Errorf("chars = %d, want 5", chars)
+	}
+	if seconds != 1 {
+		t.Errorf("seconds = %.2f, want 1", seconds)
+	}
+
+	// A 20 WPM ghost is 2 runes behind and needs another second to catch up
+	ghost = &Ghost{Text: test.TargetText, WPM: 20}
+	chars, seconds = ghost.Gap(test)
+	if chars != -2 || seconds != -1 {
+		t.Errorf("Gap() = %d, %.2f, want -2, -1", chars, seconds)
+	}
+}
@@ -0,0 +1,345 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Ways an APIStep picks one item from a list
+const (
+	PickRandom = "random"
+	PickFirst  = "first"
+)
+
+const apiAttempts = 3 // Chains that end without text are retried this many times
+
+var errNoAPIText = errors.New("no text found")
+
+// apiTemplateVar matches {{name}} in URLs and limits
+var apiTemplateVar = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// APIDefinition describes a plugin built from JSON APIs, read from a .json
+// file in the plugin directory. Each step fetches a URL and picks an item
+// that the next step's URL can refer to; the text, URL and author are then
+// selected from the last one.
+type APIDefinition struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Category    string    `json:"category,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Settings    []Setting `json:"settings,omitempty"`
+	Steps       []APIStep `json:"steps"`
+	Text        string    `json:"text"`             // Selector for the text to type
+	URL         string    `json:"url,omitempty"`    // Selector, or a fixed value if it doesn't start with $
+	Author      string    `json:"author,omitempty"` // Selector, or a fixed value if it doesn't start with $
+}
+
+// APIStep is one request in an APIDefinition. URL and Limit may contain
+// {{key}} for a setting and {{item}} or {{item.path}} for the item picked
+// by the previous step, escaped for use in a URL path.
+type APIStep struct {
+	URL   string `json:"url"`
+	Items string `json:"items,omitempty"` // Selector for the list to pick from; the whole response if empty
+	Pick  string `json:"pick,omitempty"`  // PickRandom or PickFirst
+	Limit string `json:"limit,omitempty"` // Only pick from this many leading items
+}
+
+func isDefinition(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// loadDefinition reads and checks a definition file
+func loadDefinition(path string) (*Registration, error) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	var def APIDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid definition: %w", name, err)
+	}
+	if def.Name == "" {
+		def.Name = name
+	}
+	if err := def.check(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+
+	return &Registration{
+		Name:        name,
+		Description: def.Description,
+		Category:    def.Category,
+		Language:    def.Language,
+		Online:      true,
+		Factory:     func() ContentSource { return NewAPISource(def, HTTPOptions{}) },
+	}, nil
+}
+
+// check reports the first mistake in a definition, so that it fails when
+// loaded rather than on every fetch
+func (d *APIDefinition) check() error {
+	if err := checkSettings(d.Settings); err != nil {
+		return err
+	}
+	if len(d.Steps) == 0 {
+		return errors.New("no steps defined")
+	}
+	for i, step := range d.Steps {
+		if step.URL == "" {
+			return fmt.Errorf("step %d has no url", i+1)
+		}
+		if step.Pick != "" && step.Pick != PickRandom && step.Pick != PickFirst {
+			return fmt.Errorf("step %d: pick must be %s or %s", i+1, PickRandom, PickFirst)
+		}
+		if step.Items != "" {
+			if _, err := parseJSONPath(step.Items); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+		for _, tmpl := range []string{step.URL, step.Limit} {
+			if err := d.checkTemplate(tmpl, i > 0); err != nil {
+				return fmt.Errorf("step %d: %w", i+1, err)
+			}
+		}
+	}
+	if d.Text == "" {
+		return errors.New("no text selector")
+	}
+	if _, err := parseJSONPath(d.Text); err != nil {
+		return err
+	}
+	for _, sel := range []string{d.URL, d.Author} {
+		if strings.HasPrefix(sel, "$") {
+			if _, err := parseJSONPath(sel); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTemplate checks that every variable in tmpl refers to a setting, or
+// to the previous item if there is one
+func (d *APIDefinition) checkTemplate(tmpl string, hasItem bool) error {
+	for _, m := range apiTemplateVar.FindAllStringSubmatch(tmpl, -1) {
+		if path, ok := itemPath(m[1]); ok {
+			if !hasItem {
+				return fmt.Errorf("{{%s}} used before an item is picked", m[1])
+			}
+			if _, err := parseJSONPath(path); err != nil {
+				return err
+			}
+			continue
+		}
+		known := false
+		for _, s := range d.Settings {
+			known = known || s.Key == m[1]
+		}
+		if !known {
+			return fmt.Errorf("{{%s}} is not a setting", m[1])
+		}
+	}
+	return nil
+}
+
+// itemPath turns item, item.id or item[0] into a selector on the item
+func itemPath(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, "item")
+	if !ok || (rest != "" && rest[0] != '.' && rest[0] != '[') {
+		return "", false
+	}
+	return "$" + rest, true
+}
+
+// APISource runs an APIDefinition
+type APISource struct {
+	def APIDefinition
+
+	mu     sync.Mutex
+	values map[string]string
+	http   HTTPOptions
+}
+
+func NewAPISource(def APIDefinition, opts HTTPOptions) *APISource {
+	a := &APISource{def: def, http: opts.withDefaults("")}
+	a.values = Values(a, nil)
+	return a
+}
+
+func (a *APISource) Name() string {
+	return a.def.Name
+}
+
+func (a *APISource) Description() string {
+	return a.def.Description
+}
+
+func (a *APISource) Settings() []Setting {
+	return a.def.Settings
+}
+
+func (a *APISource) Configure(values map[string]string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.values = values
+	return nil
+}
+
+func (a *APISource) GetContent(ctx context.Context) (*Content, error) {
+	a.mu.Lock()
+	values := a.values
+	a.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < apiAttempts; attempt++ {
+		var content *Content
+		if content, err = a.run(ctx, values); !errors.Is(err, errNoAPIText) {
+			return content, err
+		}
+	}
+	return nil, err
+}
+
+// run fetches every step once and selects the content from the last item
+func (a *APISource) run(ctx context.Context, values map[string]string) (*Content, error) {
+	var item any
+	for i, step := range a.def.Steps {
+		u, err := expandTemplate(step.URL, values, item, urlEscaper(step.URL))
+		if err != nil {
+			return nil, err
+		}
+		body, err := a.http.fetch(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := decodeJSON(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", u, err)
+		}
+		if item, err = a.pick(step, doc, values, item); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	text, err := selectString(item, a.def.Text)
+	if err != nil {
+		return nil, err
+	}
+	if text = strings.Join(strings.Fields(text), " "); text == "" {
+		return nil, errNoAPIText
+	}
+	content := &Content{Text: text}
+	if content.SourceURL, err = selectString(item, a.def.URL); err != nil {
+		return nil, err
+	}
+	if content.Author, err = selectString(item, a.def.Author); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// pick chooses the item a step passes on, from the list its Items selector
+// finds in doc
+func (a *APISource) pick(step APIStep, doc any, values map[string]string, prev any) (any, error) {
+	if step.Items == "" {
+		return doc, nil
+	}
+	path, _ := parseJSONPath(step.Items) // Checked when loaded
+	items := path.find(doc)
+	if len(items) == 1 {
+		if list, ok := items[0].([]any); ok {
+			items = list
+		}
+	}
+
+	if step.Limit != "" {
+		limit, err := expandTemplate(step.Limit, values, prev, func(s string, _ int) string { return s })
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("limit must be a positive number, got %q", limit)
+		}
+		items = items[:min(n, len(items))]
+	}
+	if len(items) == 0 {
+		return nil, errors.New("no items found")
+	}
+	if step.Pick == PickFirst {
+		return items[0], nil
+	}
+	return items[rand.Intn(len(items))], nil
+}
+
+// expandTemplate fills in settings and parts of item, passing each through
+// escape along with where it is in tmpl
+func expandTemplate(tmpl string, values map[string]string, item any, escape func(s string, at int) string) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, m := range apiTemplateVar.FindAllStringSubmatchIndex(tmpl, -1) {
+		b.WriteString(tmpl[last:m[0]])
+		last = m[1]
+		name := tmpl[m[2]:m[3]]
+		if path, ok := itemPath(name); ok {
+			s, err := selectString(item, path)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(escape(s, m[0]))
+			continue
+		}
+		b.WriteString(escape(values[name], m[0]))
+	}
+	b.WriteString(tmpl[last:])
+	return b.String(), nil
+}
+
+// urlEscaper escapes values by where they go in the URL template tmpl: as
+// they are at the very start, so a setting can hold a base URL, for a query
+// after the ? and for a path segment anywhere else
+func urlEscaper(tmpl string) func(s string, at int) string {
+	query := strings.IndexByte(tmpl, '?')
+	return func(s string, at int) string {
+		switch {
+		case at == 0:
+			return s
+		case query >= 0 && at > query:
+			return url.QueryEscape(s)
+		default:
+			return url.PathEscape(s)
+		}
+	}
+}
+
+// selectString returns the first value sel matches in doc as text. A sel
+// not starting with $ is returned as it is.
+func selectString(doc any, sel string) (string, error) {
+	if !strings.HasPrefix(sel, "$") {
+		return sel, nil
+	}
+	path, err := parseJSONPath(sel)
+	if err != nil {
+		return "", err
+	}
+	matches := path.find(doc)
+	if len(matches) == 0 {
+		return "", nil
+	}
+	s, ok := jsonString(matches[0])
+	if !ok {
+		return "", fmt.Errorf("%s selects a list or object, not text", sel)
+	}
+	return s, nil
+}
@@ -0,0 +1,240 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// apiServer serves each response at its path, with the query ignored
+func apiServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requested
+}
+
+// hnDefinition is the Hacker News plugin written as a definition
+func hnDefinition(baseURL string) APIDefinition {
+	return APIDefinition{
+		Name: "HN",
+		Settings: []Setting{
+			{Key: "stories", Label: "Stories", Type: SettingInt, Default: "1"},
+		},
+		Steps: []APIStep{
+			{URL: baseURL + "/topstories.json", Items: "$", Limit: "{{stories}}"},
+			{URL: baseURL + "/item/{{item}}.json"},
+		},
+		Text:   "$.title",
+		URL:    "$.url",
+		Author: "$.by",
+	}
+}
+
+func TestAPISource_GetContent(t *testing.T) {
+	ts, requested := apiServer(t, map[string]string{
+		"/topstories.json": `[8863, 8864]`,
+		"/item/8863.json":  `{"id": 8863, "title": "My  YC app:\nDropbox", "url": "https://dropbox.com", "by": "dhouston"}`,
+	})
+
+	def := hnDefinition(ts.URL)
+	if err := def.check(); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAPISource(def, HTTPOptions{})
+	content, err := a.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Content{Text: "My YC app: Dropbox", SourceURL: "https://dropbox.com", Author: "dhouston"}
+	if *content != want {
+		t.Errorf("content = %+v, want %+v", *content, want)
+	}
+	if got := strings.Join(*requested, " "); got != "/topstories.json /item/8863.json" {
+		t.Errorf("requested %s, want the list and then the first item", got)
+	}
+}
+
+func TestAPISource_Templates(t *testing.T) {
+	ts, requested := apiServer(t, map[string]string{
+		"/r/golang/top.json":        `{"data": {"children": [{"data": {"title": "Go 1.22 is released", "permalink": "/r/golang/comments/1", "author": "gopher"}}]}}`,
+		"/r/golang/comments/1.json": `{"title": "unused"}`,
+	})
+
+	def := APIDefinition{
+		Settings: []Setting{{Key: "sub", Label: "Subreddit", Type: SettingString, Default: "golang"}},
+		Steps: []APIStep{
+			{URL: ts.URL + "/r/{{ sub }}/top.json?t=week", Items: "$.data.children[*].data", Pick: PickFirst},
+		},
+		Text:   "$.title",
+		URL:    "$.permalink",
+		Author: "Reddit",
+	}
+	if err := def.check(); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAPISource(def, HTTPOptions{})
+	content, err := a.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "Go 1.22 is released" || content.SourceURL != "/r/golang/comments/1" || content.Author != "Reddit" {
+		t.Errorf("content = %+v, want the first post with a fixed author", content)
+	}
+	if (*requested)[0] != "/r/golang/top.json?t=week" {
+		t.Errorf("requested %v, want the setting filled in", *requested)
+	}
+
+	// Settings are escaped before they go into a URL
+	if err := Configure(a, map[string]string{"sub": "a b/c"}); err != nil {
+		t.Fatal(err)
+	}
+	a.GetContent(context.Background())
+	if got := (*requested)[len(*requested)-1]; got != "/r/a%20b%2Fc/top.json?t=week" {
+		t.Errorf("requested %s, want the setting escaped", got)
+	}
+}
+
+func TestAPISource_URLEscaping(t *testing.T) {
+	ts, requested := apiServer(t, map[string]string{
+		"/v1/search/new items": `{"title": "Found it"}`,
+	})
+
+	def := APIDefinition{
+		Settings: []Setting{
+			{Key: "base", Label: "API", Type: SettingString},
+			{Key: "kind", Label: "Kind", Type: SettingString, Default: "new items"},
+			{Key: "query", Label: "Query", Type: SettingString},
+		},
+		Steps: []APIStep{{URL: "{{base}}/search/{{kind}}?q={{query}}&n=1"}},
+		Text:  "$.title",
+	}
+	if err := def.check(); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAPISource(def, HTTPOptions{})
+	if err := Configure(a, map[string]string{"base": ts.URL + "/v1", "query": "a&b=c+d e"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.GetContent(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The base URL keeps its slashes, and the query can't add parameters
+	if got, want := (*requested)[0], "/v1/search/new%20items?q=a%26b%3Dc%2Bd+e&n=1"; got != want {
+		t.Errorf("requested %s, want %s", got, want)
+	}
+}
+
+func TestAPISource_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses map[string]string
+		want      string
+		requests  int
+	}{
+		{"NoItems", map[string]string{"/topstories.json": `[]`}, "no items found", 1},
+		{"NotJSON", map[string]string{"/topstories.json": `<html>`}, "failed to decode", 1},
+		{"NoText", map[string]string{"/topstories.json": `[1]`, "/item/1.json": `{"title": " "}`}, "no text found", 2 * apiAttempts},
+		{"ObjectText", map[string]string{"/topstories.json": `[1]`, "/item/1.json": `{"title": {"en": "Hi"}}`}, "not text", 2},
+		{"HTTPError", map[string]string{"/topstories.json": `[1]`}, "404", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, requested := apiServer(t, tt.responses)
+			a := NewAPISource(hnDefinition(ts.URL), HTTPOptions{})
+			_, err := a.GetContent(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+			if len(*requested) != tt.requests {
+				t.Errorf("made %d requests, want %d", len(*requested), tt.requests)
+			}
+		})
+	}
+}
+
+func TestAPIDefinition_Check(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(d *APIDefinition)
+		want string
+	}{
+		{"NoSteps", func(d *APIDefinition) { d.Steps = nil }, "no steps"},
+		{"NoText", func(d *APIDefinition) { d.Text = "" }, "no text selector"},
+		{"BadText", func(d *APIDefinition) { d.Text = "title" }, "must start with $"},
+		{"BadItems", func(d *APIDefinition) { d.Steps[0].Items = "$[" }, "step 1"},
+		{"BadPick", func(d *APIDefinition) { d.Steps[0].Pick = "best" }, "pick must be"},
+		{"UnknownSetting", func(d *APIDefinition) { d.Steps[0].URL += "?n={{count}}" }, "{{count}} is not a setting"},
+		{"ItemTooEarly", func(d *APIDefinition) { d.Steps[0].URL += "?id={{item.id}}" }, "before an item is picked"},
+		{"BadDefault", func(d *APIDefinition) { d.Settings[0].Default = "many" }, "bad default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := hnDefinition("https://example.com")
+			def.Steps = append([]APIStep{}, def.Steps...)
+			def.Settings = append([]Setting{}, def.Settings...)
+			tt.edit(&def)
+			if err := def.check(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadExternal_Definitions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"test-api.json":    `{"description": "From a JSON API", "language": "en", "steps": [{"url": "https://example.com/list.json", "items": "$[*]"}], "text": "$.title"}`,
+		"test-broken.json": `{"steps": []}`,
+		"test-syntax.json": `{"steps": [`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unregister(t, "test-api", "test-broken", "test-syntax")
+
+	err := LoadExternal(dir)
+	if err == nil || !strings.Contains(err.Error(), "test-broken: no steps") || !strings.Contains(err.Error(), "test-syntax: invalid definition") {
+		t.Errorf("err = %v, want the broken definitions reported", err)
+	}
+	r, ok := Lookup("test-api")
+	if !ok {
+		t.Fatal("test-api was not registered")
+	}
+	if !r.Online || r.Language != "en" || r.Description != "From a JSON API" {
+		t.Errorf("registration = %+v, want the defined details", r)
+	}
+	if name := r.Factory().Name(); name != "test-api" {
+		t.Errorf("Name() = %q, want the file name when none is given", name)
+	}
+}
+
+func TestExampleDefinitions(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "examples", "api", "*.json"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example definitions found: %v", err)
+	}
+	for _, path := range paths {
+		if _, err := loadDefinition(path); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	defaultArticleSentences = 3
+	maxArticleSentences     = 20
+	maxArticleRunes         = 1200 // Long sentences are cut to whole words past this
+	minArticleParagraph     = 40   // Shorter paragraphs are captions, bylines and buttons
+	maxArticleLinkDensity   = 0.5  // Paragraphs that are mostly links are lists of links
+)
+
+var (
+	// Classes and IDs of page furniture, and of the parts likely to hold the article
+	unlikelyCandidates = regexp.MustCompile(`(?i)\b(ad|ads|advert\w*|banner|breadcrumbs?|comments?|cookies?|consent|footer|masthead|menu|modal|nav\w*|newsletter|outbrain|popup|promo\w*|related|share|sharing|sidebar|social|sponsor\w*|subscri\w*|taboola|tags|widget)\b`)
+	likelyCandidates   = regexp.MustCompile(`(?i)\b(article\w*|body|column|content|entry|main|post|story)\b`)
+
+	// Common abbreviations whose full stop doesn't end a sentence
+	abbreviation = regexp.MustCompile(`(?i)(^|\s)(mr|mrs|ms|dr|prof|st|sr|jr|vs|etc|inc|ltd|e\.g|i\.e|u\.s|[a-z])\.$`)
+)
+
+var errNoArticle = errors.New("no article text found")
+
+// Elements that run on within a line of text; the rest break words apart
+var inlineElements = map[atom.Atom]bool{
+	atom.A: true, atom.Abbr: true, atom.B: true, atom.Bdi: true, atom.Bdo: true,
+	atom.Cite: true, atom.Code: true, atom.Data: true, atom.Dfn: true, atom.Em: true,
+	atom.I: true, atom.Kbd: true, atom.Label: true, atom.Mark: true, atom.Q: true,
+	atom.S: true, atom.Samp: true, atom.Small: true, atom.Span: true, atom.Strong: true,
+	atom.Sub: true, atom.Sup: true, atom.Time: true, atom.U: true, atom.Var: true,
+}
+
+// Elements that never hold the text of an article
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true, atom.Iframe: true, atom.Svg: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Dialog: true,
+}
+
+// articleText fetches the page at pageURL and returns its first sentences
+func articleText(ctx context.Context, opts HTTPOptions, pageURL string, sentences int) (string, error) {
+	body, contentType, err := opts.fetchPage(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+	paragraphs, err := extractArticle(body, contentType)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", pageURL, err)
+	}
+	return firstSentences(paragraphs, sentences), nil
+}
+
+// extractArticle finds the main text of a web page in the way of
+// Readability: paragraphs add to the score of the element around them, and
+// the best scoring element, less its links, is taken to be the article.
+// The page is decoded from the charset its Content-Type or <meta> names.
+func extractArticle(page []byte, contentType string) ([]string, error) {
+	if len(bytes.TrimSpace(page)) == 0 {
+		return nil, errNoArticle
+	}
+	r, err := charset.NewReader(bytes.NewReader(page), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page: %w", err)
+	}
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+	prune(doc)
+
+	// Each paragraph scores for its parent, and half as much for its grandparent
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node // In document order, so ties go to the first
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.P {
+			text := nodeText(n)
+			if utf8.RuneCountInString(text) >= minArticleParagraph/2 {
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				for parent, share := n.Parent, 1.0; parent != nil && share >= 0.5; parent, share = parent.Parent, share/2 {
+					if _, ok := scores[parent]; !ok {
+						scores[parent] = initialScore(parent)
+						candidates = append(candidates, parent)
+					}
+					scores[parent] += score * share
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		if score := scores[n] * (1 - linkDensity(n)); score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return nil, errNoArticle
+	}
+
+	// Paragraphs, and list items and quotes written without them
+	var paragraphs []string
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || (n.DataAtom == atom.Li || n.DataAtom == atom.Blockquote) && !hasParagraph(n)) {
+			text := nodeText(n)
+			if utf8.RuneCountInString(text) >= minArticleParagraph && linkDensity(n) < maxArticleLinkDensity {
+				paragraphs = append(paragraphs, text)
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(best)
+	if len(paragraphs) == 0 {
+		return nil, errNoArticle
+	}
+	return paragraphs, nil
+}
+
+// prune removes scripts, navigation, ads and other page furniture
+func prune(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isFurniture(c)) {
+			n.RemoveChild(c)
+		} else {
+			prune(c)
+		}
+		c = next
+	}
+}
+
+func isFurniture(n *html.Node) bool {
+	if skippedElements[n.DataAtom] {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	if _, hidden := attr(n, "hidden"); hidden {
+		return true
+	}
+	if role, _ := attr(n, "role"); role == "navigation" || role == "complementary" || role == "banner" {
+		return true
+	}
+	if ariaHidden, _ := attr(n, "aria-hidden"); ariaHidden == "true" {
+		return true
+	}
+	names := classAndID(n)
+	return unlikelyCandidates.MatchString(names) && !likelyCandidates.MatchString(names)
+}
+
+// initialScore favours elements whose tag or class suggest an article
+func initialScore(n *html.Node) float64 {
+	var score float64
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		score = 10
+	case atom.Div, atom.Section:
+		score = 5
+	case atom.Td, atom.Blockquote, atom.Pre:
+		score = 3
+	case atom.Ol, atom.Ul, atom.Li, atom.Dl, atom.Dd, atom.Dt, atom.Form, atom.Address:
+		score = -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		score = -5
+	}
+	names := classAndID(n)
+	if likelyCandidates.MatchString(names) {
+		score += 25
+	}
+	if unlikelyCandidates.MatchString(names) {
+		score -= 25
+	}
+	return score
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func classAndID(n *html.Node) string {
+	class, _ := attr(n, "class")
+	id, _ := attr(n, "id")
+	return class + " " + id
+}
+
+func hasParagraph(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.P || hasParagraph(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeText returns the text inside n with whitespace collapsed
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && !inlineElements[n.DataAtom] {
+			b.WriteByte(' ')
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// linkDensity is the share of the text in n that is inside links
+func linkDensity(n *html.Node) float64 {
+	total := len(nodeText(n))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linked += len(nodeText(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linked) / float64(total)
+}
+
+// firstSentences joins paragraphs and returns their first n sentences, cut
+// to whole words if they run very long
+func firstSentences(paragraphs []string, n int) string {
+	var picked []string
+	for _, p := range paragraphs {
+		for _, sentence := range splitSentences(p) {
+			if len(picked) == n {
+				break
+			}
+			picked = append(picked, sentence)
+		}
+	}
+	text := strings.Join(picked, " ")
+	if utf8.RuneCountInString(text) > maxArticleRunes {
+		text = cutAtSentence(text, maxArticleRunes)
+	}
+	return text
+}
+
+// splitSentences splits a paragraph after each full stop, question or
+// exclamation mark followed by a space, except after common abbreviations
+func splitSentences(p string) []string {
+	var sentences []string
+	start := 0
+	for _, loc := range sentenceEnding.FindAllStringIndex(p, -1) {
+		end := loc[1] - 1
+		if abbreviation.MatchString(strings.TrimRight(p[start:end], `"')]`)) {
+			continue
+		}
+		sentences = append(sentences, strings.TrimSpace(p[start:end]))
+		start = end
+	}
+	if rest := strings.TrimSpace(p[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
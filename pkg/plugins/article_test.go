@@ -0,0 +1,183 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "articles", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestExtractArticle(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		contentType string
+		want        []string
+	}{
+		{"news.html", "text/html; charset=utf-8", []string{
+			"The city opened its first library of things on Saturday, lending out drills, tents and sewing machines instead of books. Residents queued around the block before the doors opened at nine.",
+			`"Most people use a drill for about thirteen minutes in its whole life," said Dr. Maria Lopez, who runs the project. "It makes more sense to share one." The library already has more than 400 items, donated by local shops and families.`,
+			"Members pay a small yearly fee, based on what they can afford, and can borrow up to five items at a time. Volunteers check every item when it comes back and repair what they can.",
+			"The council hopes to open two more branches next year, if the first one proves popular.",
+		}},
+		// The charset only appears in a <meta> tag
+		{"blog-latin1.html", "text/html", []string{
+			"El café de la esquina abrió en 1925 y todavía sirve el mismo desayuno a los vecinos del barrio. Muchos clientes vienen cada mañana desde hace décadas.",
+			"Su dueña, Lucía, dice que el secreto está en el pan. Lo hornea ella misma antes del amanecer, como hacía su abuela.",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got, err := extractArticle(readFixture(t, tt.fixture), tt.contentType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paragraphs =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractArticle_NoArticle(t *testing.T) {
+	for _, page := range [][]byte{readFixture(t, "links.html"), []byte("<html><body><p>Short.</p></body></html>"), nil} {
+		if _, err := extractArticle(page, "text/html"); err == nil || !strings.Contains(err.Error(), "no article text") {
+			t.Errorf("err = %v, want no article found", err)
+		}
+	}
+}
+
+func TestFirstSentences(t *testing.T) {
+	paragraphs := []string{
+		"Dr. Lopez runs it. It opened on Saturday, e.g. at nine!",
+		`She said "share it." Is that new? Not really.`,
+	}
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "Dr. Lopez runs it."},
+		{2, "Dr. Lopez runs it. It opened on Saturday, e.g. at nine!"},
+		{3, `Dr. Lopez runs it. It opened on Saturday, e.g. at nine! She said "share it."`},
+		{10, `Dr. Lopez runs it. It opened on Saturday, e.g. at nine! She said "share it." Is that new? Not really.`},
+	}
+	for _, tt := range tests {
+		if got := firstSentences(paragraphs, tt.n); got != tt.want {
+			t.Errorf("firstSentences(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+
+	long := strings.Repeat("word ", maxArticleRunes) + "end."
+	if got := firstSentences([]string{long}, 1); len(got) > maxArticleRunes {
+		t.Errorf("len = %d, want at most %d", len(got), maxArticleRunes)
+	}
+}
+
+// articleServer serves the news fixture at /article and other kinds of
+// response next to it
+func articleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	news := readFixture(t, "news.html")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(news)
+		case "/paper.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		case "/huge":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body><div>"))
+			for i := 0; i < maxPageSize/100+10; i++ {
+				w.Write([]byte(strings.Repeat("x", 99) + " "))
+			}
+			w.Write([]byte("</div><p>" + strings.Repeat("Past the limit, so never read. ", 5) + "</p></body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestArticleText(t *testing.T) {
+	ts := articleServer(t)
+	opts := HTTPOptions{}.withDefaults("")
+
+	text, err := articleText(context.Background(), opts, ts.URL+"/article", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "The city opened its first library of things on Saturday, lending out drills, tents and sewing machines instead of books. Residents queued around the block before the doors opened at nine."
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+
+	for _, path := range []string{"/paper.pdf", "/huge", "/missing"} {
+		if _, err := articleText(context.Background(), opts, ts.URL+path, 2); err == nil {
+			t.Errorf("%s: expected an error", path)
+		}
+	}
+}
+
+func TestHackerNewsSource_Article(t *testing.T) {
+	pages := articleServer(t)
+	ts := hnServer(t, `[1, 2]`, map[string]string{
+		"/item/1.json": `{"id": 1, "by": "ada", "title": "Ask HN: No link?", "text": "None"}`,
+		"/item/2.json": `{"id": 2, "by": "bob", "title": "City opens library of things", "url": "` + pages.URL + `/article"}`,
+	})
+
+	plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+	if err := Configure(plugin, map[string]string{"kind": HNKindArticle, "sentences": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := plugin.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Content{
+		Text:      "The city opened its first library of things on Saturday, lending out drills, tents and sewing machines instead of books.",
+		SourceURL: pages.URL + "/article",
+		Author:    "bob",
+	}
+	if *content != want {
+		t.Errorf("content = %+v, want %+v", *content, want)
+	}
+}
+
+func TestFeedSource_Article(t *testing.T) {
+	pages := articleServer(t)
+	rss := `<rss version="2.0"><channel><title>Daily Example</title>
+<item><title>A PDF</title><link>` + pages.URL + `/paper.pdf</link></item>
+<item><title>City opens library of things</title><link>` + pages.URL + `/article</link></item>
+</channel></rss>`
+	ts, _ := feedServer(t, map[string]string{"/rss": rss})
+
+	f := NewFeedSource(HTTPOptions{})
+	if err := Configure(f, map[string]string{"feeds": ts.URL + "/rss", "text": FeedTextArticle, "sentences": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		content, err := f.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(content.Text, "The city opened") || content.SourceURL != pages.URL+"/article" || content.Author != "Daily Example" {
+			t.Errorf("content = %+v, want the start of the readable article", content)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"go-racer/pkg/config"
+)
+
+const (
+	// DefaultPoolSize is how many unread items a Cache keeps ready
+	DefaultPoolSize = 10
+
+	maxServed     = 50             // Items kept after use as an offline fallback
+	maxPoolAge    = 24 * time.Hour // Older unread items are only used as a fallback
+	refillTimeout = 30 * time.Second
+)
+
+// Cache wraps a ContentSource with a disk-backed pool of prefetched items.
+// Content is served from the pool instantly while the pool is refilled in the
+// background. When the source cannot be reached, previously served items are
+// returned instead, marked Stale.
+type Cache struct {
+	src  ContentSource
+	path string
+	size int
+
+	srcMu sync.RWMutex // Held to read from src, and exclusively to configure it
+
+	mu         sync.Mutex
+	options    map[string]string // Settings the cached items were fetched with
+	pool       []cachedItem
+	served     []cachedItem
+	refilling  bool
+	stopRefill context.CancelFunc // Cancels the background refill
+	wg         sync.WaitGroup     // Tracks the background refill, for tests
+}
+
+type cachedItem struct {
+	Content   Content   `json:"content"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+type cacheFile struct {
+	Options map[string]string `json:"options,omitempty"`
+	Pool    []cachedItem      `json:"pool"`
+	Served  []cachedItem      `json:"served"`
+}
+
+// NewCache wraps src with a pool stored at path. options are the settings
+// src was configured with; a stored pool fetched with other settings is
+// discarded.
+func NewCache(src ContentSource, path string, options map[string]string) *Cache {
+	c := &Cache{
+		src:     src,
+		path:    path,
+		size:    DefaultPoolSize,
+		options: options,
+	}
+	c.load()
+	return c
+}
+
+// WithCache wraps the named plugin in a Cache stored in dir if the plugin
+// needs the network. Offline plugins and an empty dir return src unchanged.
+func WithCache(name string, src ContentSource, stored map[string]string, dir string) ContentSource {
+	r, ok := Lookup(name)
+	if !ok || !r.Online || dir == "" {
+		return src
+	}
+	var options map[string]string
+	if c, ok := src.(Configurable); ok {
+		options = Values(c, stored)
+	}
+	return NewCache(src, filepath.Join(dir, r.Name+".json"), options)
+}
+
+func (c *Cache) Name() string        { return c.src.Name() }
+func (c *Cache) Description() string { return c.src.Description() }
+
+// Settings passes through the settings of the wrapped source
+func (c *Cache) Settings() []Setting {
+	if s, ok := c.src.(Configurable); ok {
+		return s.Settings()
+	}
+	return nil
+}
+
+// Configure reconfigures the wrapped source. A background refill is stopped
+// first, as sources aren't safe to configure while they fetch. Cached items
+// are dropped when the settings change, as they no longer match what the
+// user asked for.
+func (c *Cache) Configure(values map[string]string) error {
+	s, ok := c.src.(Configurable)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	if c.stopRefill != nil {
+		c.stopRefill()
+	}
+	c.mu.Unlock()
+
+	c.srcMu.Lock()
+	err := s.Configure(values)
+	c.srcMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !reflect.DeepEqual(c.options, values) {
+		c.options = values
+		c.pool, c.served = nil, nil
+		c.save()
+	}
+	return nil
+}
+
+// GetContent serves the next pooled item, fetching directly when the pool is
+// empty and falling back to an earlier item when that fails
+func (c *Cache) GetContent(ctx context.Context) (*Content, error) {
+	defer c.startRefill()
+
+	c.mu.Lock()
+	if len(c.pool) > 0 {
+		item := c.pool[0]
+		c.pool = c.pool[1:]
+		c.remember(item)
+		c.save()
+		c.mu.Unlock()
+		content := item.Content
+		return &content, nil
+	}
+	c.mu.Unlock()
+
+	c.srcMu.RLock()
+	content, err := c.src.GetContent(ctx)
+	c.srcMu.RUnlock()
+	if err == nil {
+		c.mu.Lock()
+		c.remember(cachedItem{Content: *content, FetchedAt: time.Now()})
+		c.save()
+		c.mu.Unlock()
+		return content, nil
+	}
+
+	// Offline or failing: reuse something typed before rather than nothing
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.served) == 0 || ctx.Err() == context.Canceled {
+		return nil, err
+	}
+	stale := c.served[rand.Intn(len(c.served))].Content
+	stale.Stale = true
+	return &stale, nil
+}
+
+// startRefill tops the pool up in the background unless a refill is running
+func (c *Cache) startRefill() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refilling || len(c.pool) >= c.size {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.refilling, c.stopRefill = true, cancel
+	c.wg.Add(1)
+	go c.refill(ctx)
+}
+
+// refill fetches until the pool is full. Once ctx is cancelled it stops, and
+// drops anything fetched with the settings it started with.
+func (c *Cache) refill(ctx context.Context) {
+	defer c.wg.Done()
+	defer func() {
+		c.mu.Lock()
+		c.refilling, c.stopRefill = false, nil
+		c.mu.Unlock()
+	}()
+
+	// Random sources repeat themselves, so give up after a few duplicates
+	for attempts := 0; attempts < 2*c.size; attempts++ {
+		c.mu.Lock()
+		full := len(c.pool) >= c.size
+		c.mu.Unlock()
+		if full {
+			return
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, refillTimeout)
+		c.srcMu.RLock()
+		content, err := c.src.GetContent(fetchCtx)
+		c.srcMu.RUnlock()
+		cancel()
+		if err != nil {
+			return // Probably offline; try again after the next read
+		}
+
+		c.mu.Lock()
+		if ctx.Err() != nil {
+			c.mu.Unlock()
+			return
+		}
+		if !c.contains(content.Text) {
+			c.pool = append(c.pool, cachedItem{Content: *content, FetchedAt: time.Now()})
+			c.save()
+		}
+		c.mu.Unlock()
+	}
+}
+
+// contains reports whether text is already pooled or was recently served.
+// Callers must hold c.mu.
+func (c *Cache) contains(text string) bool {
+	for _, items := range [][]cachedItem{c.pool, c.served} {
+		for _, item := range items {
+			if item.Content.Text == text {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// remember keeps a served item as an offline fallback. Callers must hold c.mu.
+func (c *Cache) remember(item cachedItem) {
+	c.served = append(c.served, item)
+	if len(c.served) > maxServed {
+		c.served = c.served[len(c.served)-maxServed:]
+	}
+}
+
+func (c *Cache) load() {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	var f cacheFile
+	if json.Unmarshal(data, &f) != nil || !reflect.DeepEqual(f.Options, c.options) {
+		return
+	}
+
+	c.served = f.Served
+	for _, item := range f.Pool {
+		if time.Since(item.FetchedAt) > maxPoolAge {
+			c.remember(item)
+		} else {
+			c.pool = append(c.pool, item)
+		}
+	}
+}
+
+// save writes the pool to disk. Failures only cost the cache, so they are
+// ignored. Callers must hold c.mu.
+func (c *Cache) save() {
+	data, err := json.Marshal(cacheFile{Options: c.options, Pool: c.pool, Served: c.served})
+	if err != nil {
+		return
+	}
+	_ = config.WriteFileAtomic(c.path, data, 0644)
+}
@@ -0,0 +1,203 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSource returns "item 1", "item 2", ... and fails while offline is set
+type countingSource struct {
+	mu      sync.Mutex
+	calls   int
+	offline bool
+}
+
+func (s *countingSource) Name() string        { return "Counting" }
+func (s *countingSource) Description() string { return "Numbered items" }
+
+func (s *countingSource) GetContent(ctx context.Context) (*Content, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.offline {
+		return nil, errors.New("network is unreachable")
+	}
+	s.calls++
+	return &Content{Text: fmt.Sprintf("item %d", s.calls)}, nil
+}
+
+func (s *countingSource) setOffline(offline bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offline = offline
+}
+
+func TestCache_ServesFromPool(t *testing.T) {
+	src := &countingSource{}
+	c := NewCache(src, filepath.Join(t.TempDir(), "pool.json"), nil)
+
+	first, err := c.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Text != "item 1" {
+		t.Errorf("first Text = %q, want a direct fetch", first.Text)
+	}
+
+	c.wg.Wait()
+	if len(c.pool) != DefaultPoolSize {
+		t.Fatalf("pool has %d items after refilling, want %d", len(c.pool), DefaultPoolSize)
+	}
+
+	// With the network gone, pooled items are still served fresh
+	src.setOffline(true)
+	next, err := c.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Text != "item 2" || next.Stale {
+		t.Errorf("got %q (stale %v), want the first pooled item", next.Text, next.Stale)
+	}
+	c.wg.Wait()
+}
+
+func TestCache_PersistsPool(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.json")
+	src := &countingSource{}
+
+	c := NewCache(src, path, map[string]string{"feed": "a"})
+	if _, err := c.GetContent(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c.wg.Wait()
+
+	// A later run starts with the pool already filled
+	src.setOffline(true)
+	reopened := NewCache(src, path, map[string]string{"feed": "a"})
+	content, err := reopened.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "item 2" || content.Stale {
+		t.Errorf("got %q (stale %v), want the pooled item from the earlier run", content.Text, content.Stale)
+	}
+	reopened.wg.Wait()
+
+	// Items fetched with other settings are not reused
+	other := NewCache(src, path, map[string]string{"feed": "b"})
+	if len(other.pool) != 0 || len(other.served) != 0 {
+		t.Errorf("cache with other settings loaded %d pooled and %d served items", len(other.pool), len(other.served))
+	}
+}
+
+func TestCache_OfflineFallback(t *testing.T) {
+	src := &countingSource{}
+	c := NewCache(src, filepath.Join(t.TempDir(), "pool.json"), nil)
+	c.size = 0 // No prefetching, so every read goes to the source
+
+	if _, err := c.GetContent(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	src.setOffline(true)
+	content, err := c.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "item 1" || !content.Stale {
+		t.Errorf("got %q (stale %v), want the earlier item marked stale", content.Text, content.Stale)
+	}
+
+	// A cancelled load is not papered over with cached text
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.GetContent(ctx); err == nil {
+		t.Error("expected an error for a cancelled load")
+	}
+}
+
+func TestCache_NothingCached(t *testing.T) {
+	src := &countingSource{offline: true}
+	c := NewCache(src, filepath.Join(t.TempDir(), "pool.json"), nil)
+
+	if _, err := c.GetContent(context.Background()); err == nil {
+		t.Error("expected the source's error when nothing is cached")
+	}
+	c.wg.Wait()
+}
+
+func TestWithCache(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := WithCache("hn", NewHackerNewsSource(HTTPOptions{}), nil, dir).(*Cache); !ok {
+		t.Error("expected an online plugin to be cached")
+	}
+	src := NewHackerNewsSource(HTTPOptions{})
+	if got := WithCache("hn", src, nil, ""); got != ContentSource(src) {
+		t.Error("expected no cache without a directory")
+	}
+}
+
+// prefixSource numbers its items after a prefix setting. Like most sources it
+// isn't safe to configure while it fetches.
+type prefixSource struct {
+	prefix string
+	calls  int
+}
+
+func (s *prefixSource) Name() string        { return "Prefix" }
+func (s *prefixSource) Description() string { return "Numbered items after a prefix" }
+
+func (s *prefixSource) Settings() []Setting {
+	return []Setting{{Key: "prefix", Label: "Prefix", Type: SettingString, Default: "old"}}
+}
+
+func (s *prefixSource) Configure(values map[string]string) error {
+	s.prefix = values["prefix"]
+	return nil
+}
+
+func (s *prefixSource) GetContent(ctx context.Context) (*Content, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Millisecond):
+	}
+	s.calls++
+	return &Content{Text: fmt.Sprintf("%s %d", s.prefix, s.calls)}, nil
+}
+
+// Run with -race: configuring the source must wait for the background refill
+func TestCache_ConfigureDuringRefill(t *testing.T) {
+	src := &prefixSource{prefix: "old"}
+	c := NewCache(src, filepath.Join(t.TempDir(), "pool.json"), map[string]string{"prefix": "old"})
+
+	for i := 0; i < 20; i++ {
+		if _, err := c.GetContent(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		prefix := "old"
+		if i%2 == 0 {
+			prefix = "new"
+		}
+		if err := c.Configure(map[string]string{"prefix": prefix}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.wg.Wait()
+
+	// Nothing fetched with the earlier settings is left in the pool
+	c.startRefill()
+	c.wg.Wait()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, item := range c.pool {
+		if !strings.HasPrefix(item.Content.Text, "old ") {
+			t.Errorf("pooled %q after switching back to old", item.Content.Text)
+		}
+	}
+}
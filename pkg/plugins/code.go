@@ -0,0 +1,229 @@
+package plugins
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Longer blocks are skipped, as they are tiring to type in one go
+	maxFuncLines = 40
+	maxFuncBytes = 2000
+)
+
+// indentLanguages mark their blocks by indentation rather than braces
+var indentLanguages = map[string]bool{
+	".py":  true,
+	".pyw": true,
+	".nim": true,
+}
+
+// codeBlock is a function or other block as it appears in its file
+type codeBlock struct {
+	text       string
+	start, end int // Line numbers, 1-based and inclusive
+}
+
+// extractBlocks finds the typeable blocks of a source file. Go is parsed
+// properly; other languages are split up by their braces or indentation.
+func extractBlocks(path string, src []byte) ([]codeBlock, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case ext == ".go":
+		return extractFuncs(path, src)
+	case indentLanguages[ext]:
+		return extractIndentBlocks(string(src)), nil
+	default:
+		return extractBraceBlocks(string(src)), nil
+	}
+}
+
+// extractFuncs parses a Go file and returns every top-level function or
+// method with a body of a typeable size. Doc comments are left out.
+func extractFuncs(path string, src []byte) ([]codeBlock, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var funcs []codeBlock
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue // Declarations implemented in assembly have no body
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		if end.Line-start.Line+1 > maxFuncLines || end.Offset-start.Offset > maxFuncBytes {
+			continue
+		}
+		funcs = append(funcs, codeBlock{
+			text:  string(src[start.Offset:end.Offset]),
+			start: start.Line,
+			end:   end.Line,
+		})
+	}
+	return funcs, nil
+}
+
+// lineSpan is a range of lines, 0-based and inclusive
+type lineSpan struct {
+	start, end int
+}
+
+// extractBraceBlocks pairs up braces to find blocks in C-like languages.
+// Braces in comments or strings spanning several lines can confuse it, which
+// is fine for picking something to type.
+func extractBraceBlocks(src string) []codeBlock {
+	lines := strings.Split(src, "\n")
+
+	var spans []lineSpan
+	var open []int // Lines of the braces not yet closed
+	for i, line := range lines {
+		for _, c := range stripStrings(line) {
+			switch c {
+			case '{':
+				open = append(open, i)
+			case '}':
+				if len(open) > 0 {
+					spans = append(spans, lineSpan{open[len(open)-1], i})
+					open = open[:len(open)-1]
+				}
+			}
+		}
+	}
+	return outermostBlocks(lines, spans)
+}
+
+// stripStrings drops string and character literals and a trailing line
+// comment, leaving the code that braces are counted in
+func stripStrings(line string) string {
+	var b strings.Builder
+	var quote rune
+	escaped := false
+	runes := []rune(line)
+	for i, c := range runes {
+		switch {
+		case quote != 0:
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`':
+			quote = c
+		case c == '\'':
+			// Only character literals, as Rust lifetimes are unbalanced
+			if i+2 < len(runes) && (runes[i+1] == '\\' || runes[i+2] == '\'') {
+				quote = c
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			return b.String()
+		case c == '#' && i == 0:
+			return "" // Preprocessor directive
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// extractIndentBlocks finds blocks introduced by a line ending in a colon,
+// as in Python, running until the indentation drops back
+func extractIndentBlocks(src string) []codeBlock {
+	lines := strings.Split(src, "\n")
+
+	var spans []lineSpan
+	for i, line := range lines {
+		header := strings.TrimSpace(line)
+		if j := strings.Index(header, " #"); j >= 0 {
+			header = strings.TrimSpace(header[:j])
+		}
+		if !strings.HasSuffix(header, ":") {
+			continue
+		}
+
+		indent := indentWidth(line)
+		end := i
+		for j := i + 1; j < len(lines); j++ {
+			if strings.TrimSpace(lines[j]) == "" {
+				continue
+			}
+			if indentWidth(lines[j]) <= indent {
+				break
+			}
+			end = j
+		}
+
+		// Decorators belong with the definition below them
+		start := i
+		for start > 0 && strings.HasPrefix(strings.TrimSpace(lines[start-1]), "@") && indentWidth(lines[start-1]) == indent {
+			start--
+		}
+		spans = append(spans, lineSpan{start, end})
+	}
+	return outermostBlocks(lines, spans)
+}
+
+// outermostBlocks keeps the spans that are a typeable size and not inside
+// another span that is. Large functions are thereby broken down into the
+// blocks within them.
+func outermostBlocks(lines []string, spans []lineSpan) []codeBlock {
+	fits := func(s lineSpan) bool {
+		if s.end == s.start || s.end-s.start+1 > maxFuncLines {
+			return false
+		}
+		// A block opened after a closing bracket, like "} else {", is only
+		// part of a statement
+		first := strings.TrimSpace(lines[s.start])
+		if strings.HasPrefix(first, "}") || strings.HasPrefix(first, ")") || strings.HasPrefix(first, "]") {
+			return false
+		}
+		return len(strings.Join(lines[s.start:s.end+1], "\n")) <= maxFuncBytes
+	}
+
+	var blocks []codeBlock
+	for _, s := range spans {
+		if !fits(s) {
+			continue
+		}
+		nested := false
+		for _, outer := range spans {
+			if outer != s && outer.start <= s.start && outer.end >= s.end && fits(outer) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			blocks = append(blocks, codeBlock{
+				text:  dedent(lines[s.start : s.end+1]),
+				start: s.start + 1,
+				end:   s.end + 1,
+			})
+		}
+	}
+	return blocks
+}
+
+// dedent removes the indentation of the first line from every line, so
+// methods can be typed without the indentation of their class
+func dedent(lines []string) string {
+	first := lines[0]
+	prefix := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = strings.TrimRight(strings.TrimPrefix(line, prefix), " \t\r")
+	}
+	return strings.Join(out, "\n")
+}
+
+func indentWidth(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
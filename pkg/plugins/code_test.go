@@ -0,0 +1,77 @@
+package plugins
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		src  string
+		want []codeBlock
+	}{
+		{
+			name: "Go",
+			path: "main.go",
+			src:  "package main\n\n// main starts here\nfunc main() {\n\tprintln(\"{\")\n}\n",
+			want: []codeBlock{{text: "func main() {\n\tprintln(\"{\")\n}", start: 4, end: 6}},
+		},
+		{
+			name: "Braces",
+			path: "app.js",
+			src:  "const a = 1;\n\nfunction add(x, y) {\n  if (x) {\n    return x + y; // }\n  }\n  return '}';\n}\n",
+			want: []codeBlock{{text: "function add(x, y) {\n  if (x) {\n    return x + y; // }\n  }\n  return '}';\n}", start: 3, end: 8}},
+		},
+		{
+			name: "MethodsDedented",
+			path: "Point.java",
+			src:  "class Point {\n" + strings.Repeat("  int x;\n", maxFuncLines) + "  int getX() {\n    return x;\n  }\n}\n",
+			want: []codeBlock{{text: "int getX() {\n  return x;\n}", start: maxFuncLines + 2, end: maxFuncLines + 4}},
+		},
+		{
+			name: "ElseIsNotABlock",
+			path: "main.c",
+			src:  "int f(int x) {\n  if (x) {\n    return 1;\n  } else {\n    return 2;\n  }\n}\n",
+			want: []codeBlock{{text: "int f(int x) {\n  if (x) {\n    return 1;\n  } else {\n    return 2;\n  }\n}", start: 1, end: 7}},
+		},
+		{
+			name: "RustLifetimes",
+			path: "lib.rs",
+			src:  "fn first<'a>(s: &'a str) -> &'a str {\n    &s[..1]\n}\n",
+			want: []codeBlock{{text: "fn first<'a>(s: &'a str) -> &'a str {\n    &s[..1]\n}", start: 1, end: 3}},
+		},
+		{
+			name: "Indentation",
+			path: "app.py",
+			src:  "import os\n\nclass App:\n    @property\n    def name(self):  # the name\n\n        return 'app'\n\nprint(App().name)\n",
+			want: []codeBlock{{text: "class App:\n    @property\n    def name(self):  # the name\n\n        return 'app'", start: 3, end: 7}},
+		},
+		{
+			name: "NoBlocks",
+			path: "consts.h",
+			src:  "#define ANSWER 42\nint x;\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractBlocks(tt.path, []byte(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractBlocks() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractBlocks_InvalidGo(t *testing.T) {
+	if _, err := extractBlocks("broken.go", []byte("package broken\n\nfunc ( {\n")); err == nil {
+		t.Error("expected a parse error")
+	}
+}
@@ -0,0 +1,436 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	externalStopGrace   = time.Second // Time a plugin has to exit once stdin is closed
+	externalStderrLimit = 4096        // Bytes of stderr kept for error messages
+)
+
+// Time a plugin has to answer, variables so tests can allow for slow machines
+var (
+	externalDescribeTimeout = 5 * time.Second  // For describe at startup
+	externalContentTimeout  = 30 * time.Second // For each get_content
+)
+
+// Methods of the external plugin protocol
+const (
+	MethodDescribe   = "describe"
+	MethodGetContent = "get_content"
+)
+
+// ExternalRequest is written to a plugin's stdin as one line of JSON
+type ExternalRequest struct {
+	Method   string            `json:"method"`
+	Settings map[string]string `json:"settings,omitempty"` // Option values, sent with get_content
+}
+
+// ExternalResponse is read from a plugin's stdout as one line of JSON. A
+// plugin that cannot answer sets Error instead.
+type ExternalResponse struct {
+	Error string `json:"error,omitempty"`
+
+	// Answer to describe
+	Name        string    `json:"name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Language    string    `json:"language,omitempty"`
+	Online      bool      `json:"online,omitempty"`
+	Hosts       []string  `json:"hosts,omitempty"` // Hosts a WebAssembly plugin asks to fetch from
+	Settings    []Setting `json:"settings,omitempty"`
+
+	// Answer to get_content
+	Content *Content `json:"content,omitempty"`
+}
+
+// LoadExternal registers every executable, WebAssembly module and JSON API
+// definition in dir as a plugin named after the file, without its extension.
+// Each program is asked to describe itself first; those that fail to, broken
+// definitions and plugins whose name is taken are skipped and reported in the
+// returned error. A missing dir is not an error.
+func LoadExternal(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read plugins: %w", err)
+	}
+
+	var paths []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !strings.HasPrefix(e.Name(), ".") && (isWasm(path) || isDefinition(path) || isExecutable(path)) {
+			paths = append(paths, path)
+		}
+	}
+
+	// Describe them all at once so one slow plugin doesn't hold up the rest
+	regs := make([]*Registration, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			if isDefinition(path) {
+				regs[i], errs[i] = loadDefinition(path)
+			} else {
+				regs[i], errs[i] = describeExternal(path)
+			}
+		}(i, path)
+	}
+	wg.Wait()
+
+	for i, r := range regs {
+		if r == nil {
+			continue
+		}
+		if _, taken := Lookup(r.Name); taken {
+			errs[i] = fmt.Errorf("plugin %s: name is already taken", r.Name)
+			continue
+		}
+		Register(*r)
+	}
+	return errors.Join(errs...)
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path) // Follows symlinks
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+func isWasm(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".wasm")
+}
+
+// externalCaller sends requests to a plugin outside the binary
+type externalCaller interface {
+	call(ctx context.Context, req ExternalRequest) (*ExternalResponse, error)
+	Close() error
+}
+
+// describeExternal runs a plugin once to learn its name and settings
+func describeExternal(path string) (*Registration, error) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var src externalCaller = &ExternalSource{Path: path, describedPlugin: describedPlugin{name: name}}
+	if isWasm(path) {
+		wasm := NewWasmSource(path, describedPlugin{name: name}, nil)
+		// Compiling doesn't count against the time the plugin has to answer
+		if err := wasm.compile(context.Background()); err != nil {
+			return nil, err
+		}
+		src = wasm
+	}
+	defer src.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalDescribeTimeout)
+	defer cancel()
+	resp, err := src.call(ctx, ExternalRequest{Method: MethodDescribe})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSettings(resp.Settings); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+
+	plugin := describedPlugin{
+		name:        name,
+		displayName: resp.Name,
+		description: resp.Description,
+		settings:    resp.Settings,
+	}
+	if plugin.displayName == "" {
+		plugin.displayName = name
+	}
+	factory := func() ContentSource { return &ExternalSource{Path: path, describedPlugin: plugin} }
+	if isWasm(path) {
+		factory = func() ContentSource { return NewWasmSource(path, plugin, resp.Hosts) }
+	}
+	return &Registration{
+		Name:        name,
+		Description: resp.Description,
+		Category:    resp.Category,
+		Language:    resp.Language,
+		Online:      resp.Online || len(resp.Hosts) > 0,
+		Factory:     factory,
+	}, nil
+}
+
+// checkSettings rejects settings a plugin outside the binary declares
+// without a key, with a key the host uses, or with an invalid default
+func checkSettings(settings []Setting) error {
+	for _, s := range settings {
+		if s.Key == "" || s.Key == allowHostsKey {
+			return fmt.Errorf("setting without a key or named %s", allowHostsKey)
+		}
+		if s.Default != "" {
+			if err := s.Validate(s.Default); err != nil {
+				return fmt.Errorf("bad default: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// describedPlugin is what a plugin outside the binary says about itself
+type describedPlugin struct {
+	name        string // Registered name, used in errors
+	displayName string
+	description string
+	settings    []Setting
+}
+
+func (p describedPlugin) Name() string {
+	return p.displayName
+}
+
+func (p describedPlugin) Description() string {
+	return p.description
+}
+
+// externalContent checks the answer to get_content
+func externalContent(name string, resp *ExternalResponse) (*Content, error) {
+	if resp.Content == nil || strings.TrimSpace(resp.Content.Text) == "" {
+		return nil, fmt.Errorf("plugin %s: no text returned", name)
+	}
+	return resp.Content, nil
+}
+
+// ExternalSource is a plugin run as a separate executable, which talks JSON
+// lines over stdin and stdout. The process is started on the first request
+// and kept for later rounds; it is restarted if it exits or stops answering.
+type ExternalSource struct {
+	Path string
+	describedPlugin
+
+	values map[string]string
+
+	mu     sync.Mutex
+	proc   *externalProcess
+	stderr tailBuffer
+}
+
+func (s *ExternalSource) Settings() []Setting {
+	return s.settings
+}
+
+func (s *ExternalSource) Configure(values map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = values
+	return nil
+}
+
+func (s *ExternalSource) GetContent(ctx context.Context) (*Content, error) {
+	ctx, cancel := context.WithTimeout(ctx, externalContentTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	values := s.values
+	s.mu.Unlock()
+
+	resp, err := s.call(ctx, ExternalRequest{Method: MethodGetContent, Settings: values})
+	if err != nil {
+		return nil, err
+	}
+	return externalContent(s.name, resp)
+}
+
+// Stderr returns the last output the plugin wrote to stderr
+func (s *ExternalSource) Stderr() string {
+	return s.stderr.String()
+}
+
+// Close stops the plugin's process if it is running
+func (s *ExternalSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proc != nil {
+		s.proc.stop()
+		s.proc = nil
+	}
+	return nil
+}
+
+// call sends one request, starting the process if needed. A process that
+// times out or answers with something other than JSON is stopped, as its
+// next answer could belong to this request.
+func (s *ExternalSource) call(ctx context.Context, req ExternalRequest) (*ExternalResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.proc != nil && s.proc.exited() {
+		s.proc.stop()
+		s.proc = nil
+	}
+	if s.proc == nil {
+		proc, err := startExternal(s.Path, &s.stderr)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", s.name, err)
+		}
+		s.proc = proc
+	}
+
+	resp, err := s.proc.call(ctx, req)
+	if err != nil {
+		s.proc.stop()
+		s.proc = nil
+		return nil, s.stderr.annotate(s.name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", s.name, resp.Error)
+	}
+	return resp, nil
+}
+
+// externalProcess is a running plugin executable
+type externalProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan []byte   // Lines of stdout
+	done  chan struct{} // Closed once stdout ends
+	quit  chan struct{} // Closed by stop
+}
+
+func startExternal(path string, stderr io.Writer) (*externalProcess, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = stderr
+	cmd.WaitDelay = externalStopGrace // Don't hang on children that keep stdout open
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start: %w", err)
+	}
+
+	p := &externalProcess{
+		cmd:   cmd,
+		stdin: stdin,
+		lines: make(chan []byte),
+		done:  make(chan struct{}),
+		quit:  make(chan struct{}),
+	}
+	go p.read(stdout)
+	return p, nil
+}
+
+// read passes each non-empty line of stdout on until the process exits
+func (p *externalProcess) read(stdout io.Reader) {
+	defer close(p.done)
+	r := bufio.NewReader(stdout)
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			select {
+			case p.lines <- line:
+			case <-p.quit:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (p *externalProcess) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *externalProcess) call(ctx context.Context, req ExternalRequest) (*ExternalResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", req.Method, err)
+	}
+
+	select {
+	case line := <-p.lines:
+		var resp ExternalResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return nil, fmt.Errorf("invalid answer to %s: %w", req.Method, err)
+		}
+		return &resp, nil
+	case <-p.done:
+		return nil, fmt.Errorf("exited before answering %s", req.Method)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no answer to %s: %w", req.Method, ctx.Err())
+	}
+}
+
+// stop closes stdin so a well-behaved plugin exits on its own, and kills it
+// if it hasn't shortly after
+func (p *externalProcess) stop() {
+	close(p.quit)
+	_ = p.stdin.Close()
+
+	exited := make(chan struct{})
+	go func() {
+		_ = p.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(externalStopGrace):
+		_ = p.cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// tailBuffer keeps the last bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - externalStderrLimit; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
+
+// annotate adds the last line written to t to an error from plugin name
+func (t *tailBuffer) annotate(name string, err error) error {
+	lines := strings.Split(strings.TrimSpace(t.String()), "\n")
+	if last := lines[len(lines)-1]; last != "" {
+		return fmt.Errorf("plugin %s: %w (stderr: %s)", name, err, last)
+	}
+	return fmt.Errorf("plugin %s: %w", name, err)
+}
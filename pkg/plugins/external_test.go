@@ -0,0 +1,187 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoPlugin answers describe with one setting and get_content with the
+// setting, a round counter and its process ID
+const echoPlugin = `#!/bin/sh
+n=0
+while read -r line; do
+	case "$line" in
+	*'"describe"'*)
+		echo '{"name": "Echo", "description": "Echoes its settings", "category": "test", "settings": [{"key": "greeting", "label": "Greeting", "type": "string", "default": "hello"}]}'
+		;;
+	*'"get_content"'*)
+		n=$((n + 1))
+		greeting=$(echo "$line" | sed -n 's/.*"greeting":"\([^"]*\)".*/\1/p')
+		echo "{\"content\": {\"text\": \"$greeting $n $$\", \"author\": \"echo\"}}"
+		;;
+	esac
+done
+`
+
+// scriptPlugin answers describe and runs onContent for get_content
+func scriptPlugin(onContent string) string {
+	return `#!/bin/sh
+while read -r line; do
+	case "$line" in
+	*'"describe"'*) echo '{"name": "Script"}' ;;
+	*) ` + onContent + ` ;;
+	esac
+done
+`
+}
+
+// writePlugins writes executable scripts to a new directory
+func writePlugins(t *testing.T, scripts map[string]string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a POSIX shell")
+	}
+	dir := t.TempDir()
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// unregister removes plugins added by a test so it can run again
+func unregister(t *testing.T, names ...string) {
+	t.Cleanup(func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		for _, name := range names {
+			delete(registry, name)
+		}
+	})
+}
+
+func newTestExternalSource(t *testing.T, script string) *ExternalSource {
+	t.Helper()
+	dir := writePlugins(t, map[string]string{"plugin.sh": script})
+	s := &ExternalSource{Path: filepath.Join(dir, "plugin.sh"), describedPlugin: describedPlugin{name: "plugin"}}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestLoadExternal(t *testing.T) {
+	dir := writePlugins(t, map[string]string{
+		"test-echo.sh":   echoPlugin,
+		"test-broken.sh": "#!/bin/sh\necho not json\n",
+		"words.sh":       echoPlugin, // Taken by a built-in plugin
+		".hidden.sh":     echoPlugin,
+	})
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unregister(t, "test-echo", "test-broken")
+
+	err := LoadExternal(dir)
+	if err == nil || !strings.Contains(err.Error(), "test-broken") || !strings.Contains(err.Error(), "words: name is already taken") {
+		t.Errorf("err = %v, want the broken and clashing plugins reported", err)
+	}
+	for _, name := range []string{"test-broken", ".hidden", "readme"} {
+		if _, ok := Lookup(name); ok {
+			t.Errorf("%s was registered", name)
+		}
+	}
+
+	r, ok := Lookup("test-echo")
+	if !ok {
+		t.Fatal("test-echo was not registered")
+	}
+	if r.Description != "Echoes its settings" || r.Category != "test" || r.Online {
+		t.Errorf("registration = %+v, want the described details", r)
+	}
+
+	src, err := GetConfiguredPlugin("test-echo", map[string]string{"greeting": "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.(*ExternalSource).Close()
+	if src.Name() != "Echo" {
+		t.Errorf("Name() = %q, want the described name", src.Name())
+	}
+	content, err := src.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(content.Text, "hi 1 ") || content.Author != "echo" {
+		t.Errorf("content = %+v, want the greeting setting sent along", content)
+	}
+}
+
+func TestLoadExternal_MissingDir(t *testing.T) {
+	if err := LoadExternal(filepath.Join(t.TempDir(), "plugins")); err != nil {
+		t.Errorf("err = %v, want a missing directory ignored", err)
+	}
+}
+
+func TestExternalSource_ReusesProcess(t *testing.T) {
+	s := newTestExternalSource(t, echoPlugin)
+
+	var pids []string
+	for round := 1; round <= 3; round++ {
+		content, err := s.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without settings the greeting is empty, leaving the round and PID
+		fields := strings.Fields(content.Text)
+		if len(fields) != 2 {
+			t.Fatalf("Text = %q, want a round and a PID", content.Text)
+		}
+		if fields[0] != strconv.Itoa(round) {
+			t.Errorf("round %d answered as round %s, want one process for every round", round, fields[0])
+		}
+		pids = append(pids, fields[1])
+	}
+	if pids[0] != pids[2] {
+		t.Errorf("PIDs = %v, want the process reused", pids)
+	}
+
+	// A process that has exited is started again
+	s.Close()
+	if _, err := s.GetContent(context.Background()); err != nil {
+		t.Errorf("GetContent after Close: %v", err)
+	}
+}
+
+func TestExternalSource_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		want    string // In the error
+	}{
+		{"ErrorAnswer", scriptPlugin(`echo '{"error": "no items today"}'`), time.Second, "no items today"},
+		{"NoText", scriptPlugin(`echo '{"content": {"text": " "}}'`), time.Second, "no text"},
+		{"NotJSON", scriptPlugin(`echo oops`), time.Second, "invalid answer"},
+		{"Crash", scriptPlugin(`echo "missing API token" >&2; exit 1`), time.Second, "stderr: missing API token"},
+		{"Timeout", scriptPlugin(`sleep 5 >/dev/null 2>&1`), 200 * time.Millisecond, "deadline exceeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestExternalSource(t, tt.script)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			_, err := s.GetContent(ctx)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// What the feeds plugin types from each item
+const (
+	FeedTextTitle       = "title"
+	FeedTextDescription = "description"
+	FeedTextArticle     = "article" // The start of the linked article
+)
+
+const feedArticleAttempts = 3 // Linked pages tried before giving up on a feed
+
+const defaultFeeds = "https://feeds.bbci.co.uk/news/world/rss.xml 1 en, https://elpais.com/rss/elpais/portada.xml 1 es"
+
+func init() {
+	Register(Registration{
+		Name:        "feeds",
+		Aliases:     []string{"rss", "atom"},
+		Description: "Headlines from any RSS, Atom or RDF feeds",
+		Category:    "news",
+		Online:      true,
+		Factory:     func() ContentSource { return NewFeedSource(HTTPOptions{}) },
+	})
+}
+
+// feedSpec is one feed to pick from, as "URL [weight] [language]" in the
+// feeds setting
+type feedSpec struct {
+	URL      string
+	Weight   int    // Relative chance of picking this feed
+	Language string // Tag such as "es" or "en-GB", empty if unknown
+}
+
+// parseFeedSpec reads one entry of the feeds setting. After the URL, a number
+// is the weight and anything else the language.
+func parseFeedSpec(entry string) (feedSpec, error) {
+	fields := strings.Fields(entry)
+	if len(fields) == 0 || len(fields) > 3 {
+		return feedSpec{}, fmt.Errorf("feed %q should be a URL, weight and language", entry)
+	}
+	if err := checkFeedURL(fields[0]); err != nil {
+		return feedSpec{}, err
+	}
+
+	spec := feedSpec{URL: fields[0], Weight: 1}
+	for _, field := range fields[1:] {
+		if weight, err := strconv.Atoi(field); err == nil {
+			if weight < 1 {
+				return feedSpec{}, fmt.Errorf("feed %s: weight must be at least 1", spec.URL)
+			}
+			spec.Weight = weight
+		} else {
+			spec.Language = field
+		}
+	}
+	return spec, nil
+}
+
+// checkFeedURL makes sure a feed is fetched over http or https
+func checkFeedURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("feed %q is not an http or https URL", raw)
+	}
+	return nil
+}
+
+// speaks reports whether the feed is in language, so "es" matches "es-ES"
+func (f feedSpec) speaks(language string) bool {
+	tag := strings.ToLower(f.Language)
+	language = strings.ToLower(language)
+	return tag == language || strings.HasPrefix(tag, language+"-")
+}
+
+// FeedSource types headlines, descriptions or linked articles from a
+// weighted list of RSS 2.0, Atom and RDF feeds and the feeds in an OPML file
+type FeedSource struct {
+	mu        sync.Mutex
+	Feeds     []feedSpec
+	OPMLPath  string // Subscriptions exported from a feed reader, read on every fetch
+	Language  string // Only feeds tagged with this language are used, if set
+	Text      string // One of the FeedText constants
+	Sentences int    // Sentences of an article to type
+
+	http HTTPOptions
+}
+
+func NewFeedSource(opts HTTPOptions) *FeedSource {
+	f := &FeedSource{Text: FeedTextTitle, Sentences: defaultArticleSentences, http: opts.withDefaults("")}
+	for _, entry := range ParseList(defaultFeeds) {
+		spec, _ := parseFeedSpec(entry)
+		f.Feeds = append(f.Feeds, spec)
+	}
+	return f
+}
+
+func (f *FeedSource) Name() string {
+	return "Feeds"
+}
+
+func (f *FeedSource) Description() string {
+	return "Headlines from RSS, Atom and RDF feeds of your choice"
+}
+
+func (f *FeedSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "feeds",
+			Label:       "Feeds",
+			Description: "Comma-separated feeds, each a URL optionally followed by a weight and a language, e.g. https://example.com/rss.xml 2 en",
+			Type:        SettingList,
+			Default:     defaultFeeds,
+		},
+		{
+			Key:         "opml",
+			Label:       "OPML file",
+			Description: "Subscriptions exported from a feed reader, used along with the feeds above",
+			Type:        SettingString,
+		},
+		{
+			Key:         "language",
+			Label:       "Language",
+			Description: "Only use feeds tagged with this language, such as es; empty uses all",
+			Type:        SettingString,
+		},
+		{
+			Key:         "text",
+			Label:       "Text",
+			Description: "Type each item's headline, its description or the linked article",
+			Type:        SettingEnum,
+			Default:     FeedTextTitle,
+			Options:     []string{FeedTextTitle, FeedTextDescription, FeedTextArticle},
+		},
+		{
+			Key:         "sentences",
+			Label:       "Article sentences",
+			Description: "How much of a linked article to type",
+			Type:        SettingInt,
+			Default:     strconv.Itoa(defaultArticleSentences),
+		},
+	}
+}
+
+func (f *FeedSource) Configure(values map[string]string) error {
+	var feeds []feedSpec
+	for _, entry := range ParseList(values["feeds"]) {
+		spec, err := parseFeedSpec(entry)
+		if err != nil {
+			return err
+		}
+		feeds = append(feeds, spec)
+	}
+	opml := strings.TrimSpace(values["opml"])
+	if opml != "" {
+		var err error
+		if opml, err = expandHome(opml); err != nil {
+			return err
+		}
+	}
+	if len(feeds) == 0 && opml == "" {
+		return errors.New("no feeds configured")
+	}
+	sentences, err := strconv.Atoi(values["sentences"])
+	if err != nil || sentences < 1 || sentences > maxArticleSentences {
+		return fmt.Errorf("sentences must be between 1 and %d, got %q", maxArticleSentences, values["sentences"])
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Feeds, f.OPMLPath, f.Language, f.Text, f.Sentences = feeds, opml, strings.TrimSpace(values["language"]), values["text"], sentences
+	return nil
+}
+
+// GetContent picks a feed by weight and a random item from it. Feeds that
+// fail or have nothing to type are skipped in favour of the others.
+func (f *FeedSource) GetContent(ctx context.Context) (*Content, error) {
+	feeds, err := f.feeds()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for len(feeds) > 0 {
+		i := pickWeighted(feeds)
+		spec := feeds[i]
+		feeds = append(feeds[:i], feeds[i+1:]...)
+
+		content, err := f.fetchItem(ctx, spec.URL)
+		if err == nil {
+			return content, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", spec.URL, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// feeds returns the configured and imported feeds in the chosen language
+func (f *FeedSource) feeds() ([]feedSpec, error) {
+	f.mu.Lock()
+	all := append([]feedSpec{}, f.Feeds...)
+	opml, language := f.OPMLPath, f.Language
+	f.mu.Unlock()
+
+	if opml != "" {
+		data, err := os.ReadFile(opml)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read OPML: %w", err)
+		}
+		imported, err := parseOPML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opml, err)
+		}
+		all = append(all, imported...)
+	}
+	if language == "" {
+		return all, nil
+	}
+
+	var feeds []feedSpec
+	for _, spec := range all {
+		if spec.speaks(language) {
+			feeds = append(feeds, spec)
+		}
+	}
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("no feeds in language %q", language)
+	}
+	return feeds, nil
+}
+
+// pickWeighted returns the index of a feed, chosen in proportion to its weight
+func pickWeighted(feeds []feedSpec) int {
+	total := 0
+	for _, spec := range feeds {
+		total += spec.Weight
+	}
+	n := rand.Intn(total)
+	for i, spec := range feeds {
+		if n -= spec.Weight; n < 0 {
+			return i
+		}
+	}
+	return len(feeds) - 1
+}
+
+func (f *FeedSource) fetchItem(ctx context.Context, feedURL string) (*Content, error) {
+	body, err := f.http.fetch(ctx, feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	parsed, err := parseFeed(body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	mode, sentences := f.Text, f.Sentences
+	f.mu.Unlock()
+
+	var items []*Content
+	for _, item := range parsed.Items {
+		text := cleanTitle(item.Title, parsed.Title, feedURL)
+		switch mode {
+		case FeedTextDescription:
+			text = feedText(item.Description)
+			if utf8.RuneCountInString(text) > maxParagraphRunes {
+				text = cutAtSentence(text, maxParagraphRunes)
+			}
+		case FeedTextArticle:
+			if item.Link == "" {
+				text = ""
+			}
+		}
+		if text != "" {
+			items = append(items, &Content{Text: text, SourceURL: item.Link})
+		}
+	}
+	if len(items) == 0 {
+		return nil, errors.New("no stories found")
+	}
+
+	var content *Content
+	if mode == FeedTextArticle {
+		if content, err = f.fetchArticle(ctx, items, max(sentences, 1)); err != nil {
+			return nil, err
+		}
+	} else {
+		content = items[rand.Intn(len(items))]
+	}
+	content.Author = feedText(parsed.Title)
+	if content.Author == "" {
+		if u, err := url.Parse(feedURL); err == nil {
+			content.Author = u.Hostname()
+		}
+	}
+	return content, nil
+}
+
+// fetchArticle replaces the text of a random item with the start of the
+// article it links to, trying a few items in case some pages can't be read
+func (f *FeedSource) fetchArticle(ctx context.Context, items []*Content, sentences int) (*Content, error) {
+	var err error
+	for _, i := range rand.Perm(len(items))[:min(feedArticleAttempts, len(items))] {
+		var text string
+		if text, err = articleText(ctx, f.http, items[i].SourceURL, sentences); err == nil {
+			items[i].Text = text
+			return items[i], nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
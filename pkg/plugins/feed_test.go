@@ -0,0 +1,165 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// feedServer serves each feed at its path and counts the requests for it
+func feedServer(t *testing.T, feeds map[string]string) (*httptest.Server, map[string]int) {
+	t.Helper()
+	hits := make(map[string]int)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		body, ok := feeds[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, hits
+}
+
+func TestParseFeedSpec(t *testing.T) {
+	tests := []struct {
+		entry   string
+		want    feedSpec
+		wantErr bool
+	}{
+		{"https://example.com/rss.xml", feedSpec{URL: "https://example.com/rss.xml", Weight: 1}, false},
+		{"https://example.com/rss.xml 3 es", feedSpec{URL: "https://example.com/rss.xml", Weight: 3, Language: "es"}, false},
+		{"https://example.com/rss.xml en-GB", feedSpec{URL: "https://example.com/rss.xml", Weight: 1, Language: "en-GB"}, false},
+		{"https://example.com/rss.xml 0", feedSpec{}, true},
+		{"example.com/rss.xml", feedSpec{}, true},
+		{"file:///etc/feed.xml", feedSpec{}, true},
+		{"https://example.com/rss.xml 1 en extra", feedSpec{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseFeedSpec(tt.entry)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseFeedSpec(%q) = %+v, %v, want %+v (error %v)", tt.entry, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFeedSource_GetContent(t *testing.T) {
+	ts, _ := feedServer(t, map[string]string{"/atom": mockAtom})
+
+	f := NewFeedSource(HTTPOptions{})
+	if err := Configure(f, map[string]string{"feeds": ts.URL + "/atom"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := f.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Content{Text: "Fish & Chips", SourceURL: "https://example.com/posts/1", Author: "Example Blog"}
+	if *content != want {
+		t.Errorf("content = %+v, want %+v", *content, want)
+	}
+
+	// The description is typed instead when asked for
+	if err := Configure(f, map[string]string{"feeds": ts.URL + "/atom", "text": FeedTextDescription}); err != nil {
+		t.Fatal(err)
+	}
+	content, err = f.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "Frying fish at home." {
+		t.Errorf("Text = %q, want the description as plain text", content.Text)
+	}
+}
+
+func TestFeedSource_Language(t *testing.T) {
+	ts, hits := feedServer(t, map[string]string{"/en": mockAtom, "/es": mockRSS})
+
+	f := NewFeedSource(HTTPOptions{})
+	err := Configure(f, map[string]string{
+		"feeds":    ts.URL + "/en 5 en, " + ts.URL + "/es 1 es-ES",
+		"language": "es",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		content, err := f.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Text != "Noticia de prueba" {
+			t.Fatalf("Text = %q, want only the Spanish feed", content.Text)
+		}
+	}
+	if hits["/en"] != 0 {
+		t.Errorf("the English feed was fetched %d times", hits["/en"])
+	}
+
+	if err := Configure(f, map[string]string{"feeds": ts.URL + "/en en", "language": "fr"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.GetContent(context.Background()); err == nil || !strings.Contains(err.Error(), `no feeds in language "fr"`) {
+		t.Errorf("err = %v, want no French feeds reported", err)
+	}
+}
+
+func TestFeedSource_SkipsBrokenFeeds(t *testing.T) {
+	ts, hits := feedServer(t, map[string]string{"/good": mockRDF, "/empty": `<rss><channel></channel></rss>`})
+
+	f := NewFeedSource(HTTPOptions{})
+	err := Configure(f, map[string]string{"feeds": ts.URL + "/missing 100, " + ts.URL + "/empty 100, " + ts.URL + "/good"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := f.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Author != "Example News" {
+		t.Errorf("content = %+v, want an item from the working feed", content)
+	}
+	if hits["/good"] != 1 {
+		t.Errorf("hits = %v, want the working feed fetched once", hits)
+	}
+
+	if err := Configure(f, map[string]string{"feeds": ts.URL + "/missing, " + ts.URL + "/empty"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.GetContent(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "no stories found") {
+		t.Errorf("err = %v, want both failures reported", err)
+	}
+}
+
+func TestFeedSource_OPML(t *testing.T) {
+	ts, _ := feedServer(t, map[string]string{"/rdf": mockRDF})
+	opml := filepath.Join(t.TempDir(), "subscriptions.opml")
+	data := `<opml version="2.0"><body><outline text="News"><outline xmlUrl="` + ts.URL + `/rdf"/></outline></body></opml>`
+	if err := os.WriteFile(opml, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := NewFeedSource(HTTPOptions{})
+	if err := Configure(f, map[string]string{"feeds": "", "opml": opml}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := f.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(content.Text, "story") {
+		t.Errorf("Text = %q, want a story from the imported feed", content.Text)
+	}
+
+	if err := f.Configure(map[string]string{"feeds": ""}); err == nil {
+		t.Error("expected an error without feeds or an OPML file")
+	}
+}
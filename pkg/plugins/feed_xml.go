@@ -0,0 +1,220 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxSiteSuffixRunes = 40 // Longer title endings are part of the title
+
+var htmlEntity = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+
+// feed is an RSS 2.0, Atom or RDF feed reduced to what can be typed
+type feed struct {
+	Title string
+	Items []feedItem
+}
+
+type feedItem struct {
+	Title       string
+	Link        string
+	Description string // HTML as found in the feed
+}
+
+// feedDocument covers the three formats: RSS nests items in its channel,
+// RDF puts them beside it and Atom calls them entries
+type feedDocument struct {
+	XMLName xml.Name
+	Title   string      `xml:"title"`
+	Channel feedChannel `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type feedChannel struct {
+	Title string    `xml:"title"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	Links   []atomLink `xml:"link"`
+	Summary string     `xml:"summary"`
+	Content string     `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// parseFeed decodes an RSS 2.0, Atom or RDF feed in any encoding its XML
+// declaration names
+func parseFeed(data []byte) (*feed, error) {
+	var doc feedDocument
+	if err := newXMLDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	}
+
+	switch doc.XMLName.Local {
+	case "rss", "RDF":
+		f := &feed{Title: doc.Channel.Title}
+		for _, item := range append(doc.Channel.Items, doc.Items...) {
+			f.Items = append(f.Items, feedItem{
+				Title:       item.Title,
+				Link:        strings.TrimSpace(item.Link),
+				Description: item.Description,
+			})
+		}
+		return f, nil
+	case "feed":
+		f := &feed{Title: doc.Title}
+		for _, entry := range doc.Entries {
+			item := feedItem{Title: entry.Title, Description: entry.Summary}
+			if item.Description == "" {
+				item.Description = entry.Content
+			}
+			for _, link := range entry.Links {
+				if link.Rel == "" || link.Rel == "alternate" {
+					item.Link = link.Href
+					break
+				}
+			}
+			f.Items = append(f.Items, item)
+		}
+		return f, nil
+	default:
+		return nil, fmt.Errorf("<%s> is not an RSS, Atom or RDF feed", doc.XMLName.Local)
+	}
+}
+
+func newXMLDecoder(data []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false // Many feeds use HTML entities such as &nbsp;
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(label)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+	return d
+}
+
+// opmlDocument is an OPML subscription list, as exported by feed readers
+type opmlDocument struct {
+	Outlines []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Language string        `xml:"language,attr"`
+	Outlines []opmlOutline `xml:"outline"` // Folders nest outlines
+}
+
+// parseOPML returns every feed in an OPML file, with a weight of one
+func parseOPML(data []byte) ([]feedSpec, error) {
+	var doc opmlDocument
+	if err := newXMLDecoder(data).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode OPML: %w", err)
+	}
+
+	var specs []feedSpec
+	var walk func([]opmlOutline)
+	walk = func(outlines []opmlOutline) {
+		for _, o := range outlines {
+			if o.XMLURL != "" {
+				specs = append(specs, feedSpec{URL: o.XMLURL, Weight: 1, Language: o.Language})
+			}
+			walk(o.Outlines)
+		}
+	}
+	walk(doc.Outlines)
+	if len(specs) == 0 {
+		return nil, errors.New("no feeds found in OPML")
+	}
+	for _, spec := range specs {
+		if err := checkFeedURL(spec.URL); err != nil {
+			return nil, err
+		}
+	}
+	return specs, nil
+}
+
+// feedText turns a title or description into plain text. Markup and
+// entities escaped once more than needed only show up after the first pass,
+// so only then is there a second.
+func feedText(s string) string {
+	s = stripHTML(s)
+	if mdHTMLTag.MatchString(s) || htmlEntity.MatchString(s) {
+		s = stripHTML(s)
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripHTML drops the tags of an HTML fragment, then decodes its entities
+func stripHTML(s string) string {
+	return html.UnescapeString(mdHTMLTag.ReplaceAllString(s, " "))
+}
+
+// cleanTitle makes a headline typeable and drops a trailing site name, as
+// in "Headline - EL PAÍS", when it matches the feed title or its host
+func cleanTitle(title, feedTitle, feedURL string) string {
+	title = feedText(title)
+	sites := []string{siteKey(feedText(feedTitle))}
+	if u, err := url.Parse(feedURL); err == nil {
+		labels := strings.Split(u.Hostname(), ".")
+		for _, label := range labels[:len(labels)-1] { // feeds.elpais.com has elpais
+			sites = append(sites, siteKey(label))
+		}
+	}
+
+	for _, sep := range []string{" - ", " | ", " – ", " — ", " :: "} {
+		i := strings.LastIndex(title, sep)
+		if i <= 0 {
+			continue
+		}
+		suffix := title[i+len(sep):]
+		key := siteKey(suffix)
+		if len(key) < 3 || utf8.RuneCountInString(suffix) > maxSiteSuffixRunes {
+			continue
+		}
+		for _, site := range sites {
+			if site != "" && strings.HasPrefix(site, key) {
+				return strings.TrimSpace(title[:i])
+			}
+		}
+	}
+	return title
+}
+
+// siteKey reduces a site name to lower-case letters and digits without
+// accents, so "EL PAÍS" and elpais.com compare equal
+func siteKey(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
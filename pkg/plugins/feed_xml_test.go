@@ -0,0 +1,154 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+)
+
+const mockAtom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+ <title>Example Blog</title>
+ <link href="https://example.com/feed.atom" rel="self"/>
+ <entry>
+  <title type="html">Fish &amp;amp; Chips</title>
+  <link href="https://example.com/feed.atom#1" rel="edit"/>
+  <link href="https://example.com/posts/1"/>
+  <summary type="html">&lt;p&gt;Frying &lt;b&gt;fish&lt;/b&gt;&amp;nbsp;at home.&lt;/p&gt;</summary>
+ </entry>
+</feed>`
+
+const mockRDF = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+ <channel rdf:about="https://example.org/">
+  <title>Example News</title>
+ </channel>
+ <item rdf:about="https://example.org/1">
+  <title>First story</title>
+  <link>https://example.org/1</link>
+  <description>About the first story.</description>
+ </item>
+ <item rdf:about="https://example.org/2">
+  <title>Second story</title>
+  <link>https://example.org/2</link>
+ </item>
+</rdf:RDF>`
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		wantTitle string
+		wantItems []feedItem
+	}{
+		{"RSS", mockRSS, "Portada", []feedItem{
+			{Title: "Noticia de prueba", Link: "https://example.com/noticia"},
+		}},
+		{"Atom", mockAtom, "Example Blog", []feedItem{
+			{Title: "Fish &amp; Chips", Link: "https://example.com/posts/1", Description: "<p>Frying <b>fish</b>&nbsp;at home.</p>"},
+		}},
+		{"RDF", mockRDF, "Example News", []feedItem{
+			{Title: "First story", Link: "https://example.org/1", Description: "About the first story."},
+			{Title: "Second story", Link: "https://example.org/2"},
+		}},
+		{"Latin1", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><title>Espa\xf1a</title></channel></rss>", "España", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := parseFeed([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", f.Title, tt.wantTitle)
+			}
+			if len(f.Items) != len(tt.wantItems) {
+				t.Fatalf("Items = %+v, want %+v", f.Items, tt.wantItems)
+			}
+			for i, want := range tt.wantItems {
+				if f.Items[i] != want {
+					t.Errorf("Items[%d] = %+v, want %+v", i, f.Items[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeed_NotAFeed(t *testing.T) {
+	if _, err := parseFeed([]byte("<html><body>Hello</body></html>")); err == nil || !strings.Contains(err.Error(), "not an RSS") {
+		t.Errorf("err = %v, want HTML rejected", err)
+	}
+}
+
+func TestParseOPML(t *testing.T) {
+	data := `<?xml version="1.0"?>
+<opml version="2.0">
+ <head><title>Subscriptions</title></head>
+ <body>
+  <outline text="Top" xmlUrl="https://example.com/top.xml"/>
+  <outline text="Spanish">
+   <outline text="El País" type="rss" xmlUrl="https://elpais.com/rss/elpais/portada.xml" language="es"/>
+  </outline>
+ </body>
+</opml>`
+
+	specs, err := parseOPML([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []feedSpec{
+		{URL: "https://example.com/top.xml", Weight: 1},
+		{URL: "https://elpais.com/rss/elpais/portada.xml", Weight: 1, Language: "es"},
+	}
+	if len(specs) != len(want) || specs[0] != want[0] || specs[1] != want[1] {
+		t.Errorf("specs = %+v, want %+v", specs, want)
+	}
+
+	if _, err := parseOPML([]byte(`<opml><body></body></opml>`)); err == nil {
+		t.Error("expected an error for an OPML file without feeds")
+	}
+	for _, xmlURL := range []string{"file:///etc/passwd", "feed.xml", "https:///feed.xml"} {
+		data := `<opml><body><outline xmlUrl="` + xmlURL + `"/></body></opml>`
+		if _, err := parseOPML([]byte(data)); err == nil || !strings.Contains(err.Error(), "not an http or https URL") {
+			t.Errorf("parseOPML with %s: err = %v, want the URL refused", xmlURL, err)
+		}
+	}
+}
+
+func TestFeedText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Fish & Chips", "Fish & Chips"},
+		{"<p>Frying <b>fish</b>&nbsp;at home.</p>", "Frying fish at home."},
+		// Escaped once more than needed
+		{"&lt;p&gt;Fish &amp;amp; chips&lt;/p&gt;", "Fish & chips"},
+		// Markup that is only mentioned survives
+		{"Why &amp;lt;div&amp;gt; soup", "Why <div> soup"},
+	}
+	for _, tt := range tests {
+		if got := feedText(tt.in); got != tt.want {
+			t.Errorf("feedText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		title, feedTitle, feedURL string
+		want                      string
+	}{
+		{"Noticia de prueba - EL PAÍS", "EL PAÍS: el periódico global", "https://feeds.elpais.com/portada", "Noticia de prueba"},
+		{"Noticia de prueba | El Pais", "Portada", "https://feeds.elpais.com/portada", "Noticia de prueba"},
+		{"Markets rally - BBC News", "BBC News - World", "https://feeds.bbci.co.uk/news/world/rss.xml", "Markets rally"},
+		{"Fish &amp; Chips &#8211; a history", "Food", "https://example.com/feed", "Fish & Chips – a history"},
+		{"Spider-Man - the return", "Movies", "https://example.com/feed", "Spider-Man - the return"},
+		{"Local elections - EU", "EU Observer", "https://euobserver.com/rss", "Local elections - EU"},
+		{"  Breaking:   <b>news</b> ", "", "", "Breaking: news"},
+	}
+	for _, tt := range tests {
+		if got := cleanTitle(tt.title, tt.feedTitle, tt.feedURL); got != tt.want {
+			t.Errorf("cleanTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
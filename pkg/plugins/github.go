@@ -1,15 +1,54 @@
 package plugins
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"math/rand"
-	"net/http"
+	"slices"
+	"strings"
 	"time"
 )
 
-type GitHubSource struct{}
+const (
+	githubRawBaseURL    = "https://raw.githubusercontent.com"
+	githubWebURL        = "https://github.com"
+	defaultGitHubRepos  = "golang/go"
+	defaultGitHubBranch = "master"
+	defaultGitHubPaths  = "src/fmt/print.go,src/time/time.go,src/strings/strings.go,src/net/http/server.go"
+)
+
+func init() {
+	Register(Registration{
+		Name:        "github",
+		Aliases:     []string{"gh"},
+		Description: "Go code from GitHub repositories",
+		Category:    "code",
+		Language:    "go",
+		Online:      true,
+		Factory:     func() ContentSource { return NewGitHubSource(HTTPOptions{}) },
+	})
+}
+
+type GitHubSource struct {
+	Repos  []string // owner/name of each repository to pick from
+	Branch string
+	Paths  []string    // Files in every repository, or in one as owner/name:path
+	http   HTTPOptions // BaseURL serves raw file contents
+}
 
-func NewGitHubSource() *GitHubSource {
-	return &GitHubSource{}
+// githubFile is one file that can be picked
+type githubFile struct {
+	repo, path string
+}
+
+func NewGitHubSource(opts HTTPOptions) *GitHubSource {
+	return &GitHubSource{
+		Repos:  ParseList(defaultGitHubRepos),
+		Branch: defaultGitHubBranch,
+		Paths:  ParseList(defaultGitHubPaths),
+		http:   opts.withDefaults(githubRawBaseURL),
+	}
 }
 
 func (g *GitHubSource) Name() string {
@@ -17,58 +56,134 @@ func (g *GitHubSource) Name() string {
 }
 
 func (g *GitHubSource) Description() string {
-	return "Types out random Go snippets from the standard library"
+	return "Types out random Go functions from GitHub repositories"
 }
 
-// For simplicity, let's fetch from the Go standard library examples or a specific repo
-func (g *GitHubSource) GetContent() (*Content, error) {
-	// Let's try to get a file from the Go repo
-	// This is a simplified approach. A real implementation might use the GitHub API to search for code.
-	// For now, let's just return a hardcoded slice of interesting Go snippets if API fails or to keep it simple without auth.
+func (g *GitHubSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "repos",
+			Label:       "Repositories",
+			Description: "Comma-separated owner/name repositories to pick from",
+			Type:        SettingList,
+			Default:     defaultGitHubRepos,
+		},
+		{
+			Key:         "branch",
+			Label:       "Branch",
+			Description: "Branch the files are read from",
+			Type:        SettingString,
+			Default:     defaultGitHubBranch,
+		},
+		{
+			Key:         "paths",
+			Label:       "Files",
+			Description: "Comma-separated file paths in every repository, or owner/name:path for just one",
+			Type:        SettingList,
+			Default:     defaultGitHubPaths,
+		},
+	}
+}
+
+func (g *GitHubSource) Configure(values map[string]string) error {
+	repos := ParseList(values["repos"])
+	for _, repo := range repos {
+		if strings.Count(repo, "/") != 1 {
+			return errors.New("repositories must be given as owner/name")
+		}
+	}
+	if len(repos) == 0 {
+		return errors.New("no repositories configured")
+	}
+	paths := ParseList(values["paths"])
+	if len(paths) == 0 {
+		return errors.New("no files configured")
+	}
+	for _, path := range paths {
+		if repo, _, ok := splitGitHubPath(path); ok && !slices.Contains(repos, repo) {
+			return fmt.Errorf("%s is not one of the repositories", repo)
+		}
+	}
+	branch := strings.TrimSpace(values["branch"])
+	if branch == "" {
+		return errors.New("branch is empty")
+	}
 
-	// Actually, let's try to fetch a specific file content from raw.githubusercontent.com
-	// We can pick from a list of known interesting files.
+	g.Repos, g.Branch, g.Paths = repos, branch, paths
+	return nil
+}
 
-	files := []string{
-		"https://raw.githubusercontent.com/golang/go/master/src/fmt/print.go",
-		"https://raw.githubusercontent.com/golang/go/master/src/time/time.go",
-		"https://raw.githubusercontent.com/golang/go/master/src/strings/strings.go",
-		"https://raw.githubusercontent.com/golang/go/master/src/net/http/server.go",
+// GetContent fetches a random configured file and returns one of its
+// top-level functions or methods, exactly as written
+func (g *GitHubSource) GetContent(ctx context.Context) (*Content, error) {
+	if len(g.Repos) == 0 || len(g.Paths) == 0 {
+		return nil, errors.New("no repositories or files configured")
 	}
 
+	files := g.files()
+	if len(files) == 0 {
+		return nil, errors.New("none of the files are in the repositories")
+	}
 	rand.Seed(time.Now().UnixNano())
-	url := files[rand.Intn(len(files))]
+	file := files[rand.Intn(len(files))]
+	repo, path := file.repo, file.path
+	url := g.http.BaseURL + "/" + repo + "/" + g.Branch + "/" + path
 
-	resp, err := http.Get(url)
+	body, err := g.http.fetch(ctx, url)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Read a chunk of the file
-	// We don't want the whole file, just a function or a block.
-	// This is tricky without a parser.
-	// Let's read the first 500 bytes and find a complete line?
-	// Or maybe just grab a random function from a predefined set of snippets for stability.
-
-	// For a better experience without complex parsing, let's use a curated list of snippets for now,
-	// checking if we can fetch them. If not, we fall back to hardcoded ones.
-
-	// Let's implement a simple "random snippet from memory" for this proof of concept
-	// to ensure it works reliably without hitting API rate limits or parsing issues.
-
-	snippets := []string{
-		`testing`,
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil, fmt.Errorf("%s is empty", url)
 	}
 
-	text := snippets[rand.Intn(len(snippets))]
+	funcs, err := extractFuncs(path, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(funcs) == 0 {
+		return nil, fmt.Errorf("no functions of at most %d lines in %s", maxFuncLines, path)
+	}
+	fn := funcs[rand.Intn(len(funcs))]
 
+	anchor := fmt.Sprintf("#L%d", fn.start)
+	if fn.end > fn.start {
+		anchor += fmt.Sprintf("-L%d", fn.end)
+	}
 	return &Content{
-		Text:      text,
-		SourceURL: "https://github.com/golang/go", // Default fallback URL
+		Text:      fn.text,
+		SourceURL: githubWebURL + "/" + repo + "/blob/" + g.Branch + "/" + path + anchor,
+		Code:      true,
 	}, nil
 }
 
+// files pairs each path with the repositories it is in
+func (g *GitHubSource) files() []githubFile {
+	var files []githubFile
+	for _, path := range g.Paths {
+		if repo, file, ok := splitGitHubPath(path); ok {
+			if slices.Contains(g.Repos, repo) {
+				files = append(files, githubFile{repo, file})
+			}
+			continue
+		}
+		for _, repo := range g.Repos {
+			files = append(files, githubFile{repo, path})
+		}
+	}
+	return files
+}
+
+// splitGitHubPath splits owner/name:path, reporting false for a path that
+// doesn't name its repository
+func splitGitHubPath(path string) (repo, file string, ok bool) {
+	repo, file, ok = strings.Cut(path, ":")
+	if !ok || strings.Count(repo, "/") != 1 || file == "" {
+		return "", path, false
+	}
+	return repo, file, true
+}
+
 // Below is a scaffold for a real GitHub API implementation if we had a token
 type GitHubContent struct {
 	Content  string `json:"content"`
@@ -0,0 +1,179 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const goFile = `package greet
+
+import "fmt"
+
+// Hello greets someone
+func Hello(name string) string {
+	return fmt.Sprintf("Hello, %s!", name)
+}
+
+type Greeter struct{}
+
+func (Greeter) Greet() { fmt.Println(Hello("world")) }
+`
+
+func githubServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newTestGitHubSource(t *testing.T, url, path string) *GitHubSource {
+	t.Helper()
+	plugin := NewGitHubSource(HTTPOptions{BaseURL: url})
+	err := Configure(plugin, map[string]string{"repos": "owner/repo", "branch": "main", "paths": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plugin
+}
+
+func TestGitHubSource_GetContent(t *testing.T) {
+	ts := githubServer(t, map[string]string{"/owner/repo/main/greet/greet.go": goFile})
+	plugin := newTestGitHubSource(t, ts.URL, "greet/greet.go")
+
+	want := map[string]string{
+		"func Hello(name string) string {\n\treturn fmt.Sprintf(\"Hello, %s!\", name)\n}": "https://github.com/owner/repo/blob/main/greet/greet.go#L6-L8",
+		"func (Greeter) Greet() { fmt.Println(Hello(\"world\")) }":                        "https://github.com/owner/repo/blob/main/greet/greet.go#L12",
+	}
+
+	// Both functions turn up, each verbatim with its own line anchor
+	seen := make(map[string]bool)
+	for i := 0; i < 50 && len(seen) < len(want); i++ {
+		content, err := plugin.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		url, ok := want[content.Text]
+		if !ok {
+			t.Fatalf("unexpected text %q", content.Text)
+		}
+		if content.SourceURL != url {
+			t.Errorf("SourceURL = %q, want %q", content.SourceURL, url)
+		}
+		seen[content.Text] = true
+	}
+	if len(seen) != len(want) {
+		t.Errorf("saw %d of %d functions", len(seen), len(want))
+	}
+}
+
+func TestGitHubSource_PathsInOneRepo(t *testing.T) {
+	ts := githubServer(t, map[string]string{
+		"/owner/repo/main/greet/greet.go":    goFile,
+		"/owner/other/main/big.go":           "package big\n\nfunc Short() {}\n",
+		"/owner/repo/main/shared/shared.go":  "package shared\n\nfunc Repo() {}\n",
+		"/owner/other/main/shared/shared.go": "package shared\n\nfunc Other() {}\n",
+	})
+	plugin := NewGitHubSource(HTTPOptions{BaseURL: ts.URL})
+	err := Configure(plugin, map[string]string{
+		"repos":  "owner/repo,owner/other",
+		"branch": "main",
+		"paths":  "owner/repo:greet/greet.go,owner/other:big.go,shared/shared.go",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every pick is a file its repository has, and every file turns up
+	want := []string{
+		"https://github.com/owner/repo/blob/main/greet/greet.go",
+		"https://github.com/owner/other/blob/main/big.go",
+		"https://github.com/owner/repo/blob/main/shared/shared.go",
+		"https://github.com/owner/other/blob/main/shared/shared.go",
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 200 && len(seen) < len(want); i++ {
+		content, err := plugin.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		url, _, _ := strings.Cut(content.SourceURL, "#")
+		seen[url] = true
+	}
+	for _, url := range want {
+		if !seen[url] {
+			t.Errorf("never picked %s", url)
+		}
+	}
+
+	err = Configure(plugin, map[string]string{"repos": "owner/repo", "branch": "main", "paths": "owner/other:big.go"})
+	if err == nil || !strings.Contains(err.Error(), "owner/other is not one of the repositories") {
+		t.Errorf("err = %v, want a path in an unknown repository refused", err)
+	}
+}
+
+func TestGitHubSource_SkipsOversized(t *testing.T) {
+	long := "package big\n\nfunc Long() {\n" + strings.Repeat("\tprintln()\n", maxFuncLines) + "}\n\nfunc Short() {}\n"
+	ts := githubServer(t, map[string]string{"/owner/repo/main/big.go": long})
+	plugin := newTestGitHubSource(t, ts.URL, "big.go")
+
+	for i := 0; i < 10; i++ {
+		content, err := plugin.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Text != "func Short() {}" {
+			t.Fatalf("Text = %q, want only the short function", content.Text)
+		}
+	}
+}
+
+func TestGitHubSource_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Empty", ""},
+		{"Malformed", "package broken\n\nfunc ( {\n"},
+		{"NoFunctions", "package consts\n\nconst Answer = 42\n"},
+		{"OnlyOversized", "package big\n\nfunc Long() {\n" + strings.Repeat("\tprintln()\n", maxFuncLines) + "}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := githubServer(t, map[string]string{"/owner/repo/main/file.go": tt.body})
+			plugin := newTestGitHubSource(t, ts.URL, "file.go")
+			if _, err := plugin.GetContent(context.Background()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	t.Run("HTTPError", func(t *testing.T) {
+		ts := githubServer(t, nil)
+		plugin := newTestGitHubSource(t, ts.URL, "missing.go")
+		if _, err := plugin.GetContent(context.Background()); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestGitHubSource_Timeout(t *testing.T) {
+	ts := slowServer(t, goFile)
+
+	plugin := NewGitHubSource(HTTPOptions{BaseURL: ts.URL, Timeout: 20 * time.Millisecond})
+	if _, err := plugin.GetContent(context.Background()); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
@@ -0,0 +1,100 @@
+package plugins
+
+import (
+	"path"
+	"strings"
+)
+
+// gitignore matches paths against the rules of the .gitignore files found
+// while walking a directory. It covers the common syntax: globs, "**",
+// negation with "!", trailing "/" for directories and leading "/" anchors.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	base     string // Directory of the .gitignore, relative to the root
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool // Matched against the whole path rather than the name
+}
+
+// add reads the rules of a .gitignore in the directory base, given as a
+// slash-separated path relative to the root ("" for the root itself)
+func (g *gitignore) add(base string, data string) {
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`) // Escaped leading "#" or "!"
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = line
+		g.rules = append(g.rules, r)
+	}
+}
+
+// ignored reports whether the slash-separated path rel, relative to the root,
+// is ignored. As in git, the last matching rule wins.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			sub = rel[len(r.base)+1:]
+		}
+		if !r.anchored {
+			sub = path.Base(sub)
+		}
+		if matchGlob(strings.Split(r.pattern, "/"), strings.Split(sub, "/")) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// matchGlob matches path segments against pattern segments, where "**"
+// stands for any number of segments
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
@@ -0,0 +1,34 @@
+package plugins
+
+import "testing"
+
+func TestGitignore(t *testing.T) {
+	var g gitignore
+	g.add("", "# build output\n/bin\n*.log\n!keep.log\ntmp/\ndocs/**/*.md\n")
+	g.add("web", "dist\n")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"bin", true, true},
+		{"cmd/bin", true, false}, // Anchored to the root
+		{"debug.log", false, true},
+		{"logs/debug.log", false, true},
+		{"keep.log", false, false},
+		{"tmp", true, true},
+		{"tmp", false, false}, // Only directories
+		{"docs/guide.md", false, true},
+		{"docs/a/b/guide.md", false, true},
+		{"guide.md", false, false},
+		{"web/dist", true, true},
+		{"dist", true, false}, // Rules only apply below their .gitignore
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
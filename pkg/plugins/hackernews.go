@@ -1,23 +1,82 @@
 package plugins
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
-	"net/http"
-	"time"
+	"strconv"
+	"unicode/utf8"
 )
 
+const (
+	defaultHNStories = 50
+	hnBaseURL        = "https://hacker-news.firebaseio.com/v0"
+	hnItemURL        = "https://news.ycombinator.com/item?id=%d"
+	hnAttempts       = 10 // Stories tried before giving up on the filters
+)
+
+// Hacker News lists to pick stories from
+const (
+	HNListTop  = "top"
+	HNListNew  = "new"
+	HNListBest = "best"
+	HNListAsk  = "ask"
+	HNListShow = "show"
+	HNListJob  = "job"
+)
+
+// What the Hacker News plugin types out of a story
+const (
+	HNKindTitle   = "title"
+	HNKindComment = "comment" // The top comment
+	HNKindText    = "text"    // The body of an Ask HN or other text post
+	HNKindArticle = "article" // The start of the linked article
+)
+
+func init() {
+	Register(Registration{
+		Name:        "hn",
+		Aliases:     []string{"hackernews"},
+		Description: "Titles, top comments or posts from Hacker News",
+		Category:    "news",
+		Language:    "en",
+		Online:      true,
+		Factory:     func() ContentSource { return NewHackerNewsSource(HTTPOptions{}) },
+	})
+}
+
+// HNStory is an item from the Hacker News API: a story, job, poll or comment
 type HNStory struct {
-	ID    int    `json:"id"`
-	Title string `json:"title"`
-	URL   string `json:"url"`
+	ID      int    `json:"id"`
+	Type    string `json:"type"`
+	By      string `json:"by"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Text    string `json:"text"` // HTML
+	Score   int    `json:"score"`
+	Kids    []int  `json:"kids"` // Comments, in ranked order
+	Deleted bool   `json:"deleted"`
+	Dead    bool   `json:"dead"`
 }
 
-type HackerNewsSource struct{}
+type HackerNewsSource struct {
+	Stories   int    // Stories are picked from this many at the top of the list
+	List      string // One of the HNList constants
+	MinScore  int    // Stories with fewer points are skipped
+	Kind      string // One of the HNKind constants
+	Sentences int    // Sentences of an article to type
+	http      HTTPOptions
+}
 
-func NewHackerNewsSource() *HackerNewsSource {
-	return &HackerNewsSource{}
+func NewHackerNewsSource(opts HTTPOptions) *HackerNewsSource {
+	return &HackerNewsSource{
+		Stories:   defaultHNStories,
+		List:      HNListTop,
+		Kind:      HNKindTitle,
+		Sentences: defaultArticleSentences,
+		http:      opts.withDefaults(hnBaseURL),
+	}
 }
 
 func (h *HackerNewsSource) Name() string {
@@ -25,52 +84,180 @@ func (h *HackerNewsSource) Name() string {
 }
 
 func (h *HackerNewsSource) Description() string {
-	return "Types out the titles of top Hacker News stories"
+	return "Types out titles, top comments or Ask HN posts from Hacker News"
 }
 
-func (h *HackerNewsSource) GetContent() (*Content, error) {
-	// Fetch top stories
-	resp, err := http.Get("https://hacker-news.firebaseio.com/v0/topstories.json")
+func (h *HackerNewsSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "stories",
+			Label:       "Top Stories",
+			Description: "Pick from this many stories at the top of the list",
+			Type:        SettingInt,
+			Default:     strconv.Itoa(defaultHNStories),
+		},
+		{
+			Key:         "list",
+			Label:       "List",
+			Description: "Which stories to pick from",
+			Type:        SettingEnum,
+			Default:     HNListTop,
+			Options:     []string{HNListTop, HNListNew, HNListBest, HNListAsk, HNListShow, HNListJob},
+		},
+		{
+			Key:         "min_score",
+			Label:       "Minimum Score",
+			Description: "Skip stories with fewer points",
+			Type:        SettingInt,
+			Default:     "0",
+		},
+		{
+			Key:         "kind",
+			Label:       "Type",
+			Description: "Type the title, the top comment, the text of a post or the linked article",
+			Type:        SettingEnum,
+			Default:     HNKindTitle,
+			Options:     []string{HNKindTitle, HNKindComment, HNKindText, HNKindArticle},
+		},
+		{
+			Key:         "sentences",
+			Label:       "Article Sentences",
+			Description: "How much of a linked article to type",
+			Type:        SettingInt,
+			Default:     strconv.Itoa(defaultArticleSentences),
+		},
+	}
+}
+
+func (h *HackerNewsSource) Configure(values map[string]string) error {
+	stories, err := strconv.Atoi(values["stories"])
+	if err != nil || stories < 1 {
+		return fmt.Errorf("stories must be a positive number, got %q", values["stories"])
+	}
+	minScore, err := strconv.Atoi(values["min_score"])
+	if err != nil || minScore < 0 {
+		return fmt.Errorf("min_score must be zero or more, got %q", values["min_score"])
+	}
+	sentences, err := strconv.Atoi(values["sentences"])
+	if err != nil || sentences < 1 || sentences > maxArticleSentences {
+		return fmt.Errorf("sentences must be between 1 and %d, got %q", maxArticleSentences, values["sentences"])
+	}
+	h.Stories, h.List, h.MinScore, h.Kind, h.Sentences = stories, values["list"], minScore, values["kind"], sentences
+	return nil
+}
+
+// GetContent tries random stories from the top of the list until one passes
+// the filters and has something of the chosen kind to type
+func (h *HackerNewsSource) GetContent(ctx context.Context) (*Content, error) {
+	list := h.List
+	if list == "" {
+		list = HNListTop
+	}
+	body, err := h.http.fetch(ctx, h.http.BaseURL+"/"+list+"stories.json")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	var storyIDs []int
-	if err := json.NewDecoder(resp.Body).Decode(&storyIDs); err != nil {
-		return nil, err
+	if err := json.Unmarshal(body, &storyIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode %s stories: %w", list, err)
 	}
 
 	if len(storyIDs) == 0 {
 		return nil, fmt.Errorf("no stories found")
 	}
 
-	// Get a random story ID from the top 50
-	rand.Seed(time.Now().UnixNano())
-	randomIndex := rand.Intn(min(50, len(storyIDs)))
-	storyID := storyIDs[randomIndex]
+	candidates := storyIDs[:min(max(h.Stories, 1), len(storyIDs))]
+	var lastErr error
+	for _, i := range rand.Perm(len(candidates))[:min(hnAttempts, len(candidates))] {
+		content, err := h.fromStory(ctx, candidates[i])
+		if content != nil || (err != nil && ctx.Err() != nil) {
+			return content, err
+		}
+		if err != nil {
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("no %s stories with a score of %d or more have a %s", list, h.MinScore, h.Kind)
+}
 
-	// Fetch the story details
-	storyURL := fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", storyID)
-	resp, err = http.Get(storyURL)
+// fromStory returns the content of one story, or nil if it is filtered out
+func (h *HackerNewsSource) fromStory(ctx context.Context, id int) (*Content, error) {
+	story, err := h.item(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	if story.Deleted || story.Dead || story.Score < h.MinScore {
+		return nil, nil
+	}
 
-	var story HNStory
-	if err := json.NewDecoder(resp.Body).Decode(&story); err != nil {
+	switch h.Kind {
+	case HNKindComment:
+		for _, kid := range story.Kids {
+			comment, err := h.item(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			if !comment.Deleted && !comment.Dead && comment.Text != "" {
+				return hnContent(comment, hnText(comment.Text)), nil
+			}
+		}
+		return nil, nil
+	case HNKindText:
+		if story.Text == "" {
+			return nil, nil
+		}
+		return hnContent(story, hnText(story.Text)), nil
+	case HNKindArticle:
+		if story.URL == "" {
+			return nil, nil
+		}
+		text, err := articleText(ctx, h.http, story.URL, max(h.Sentences, 1))
+		if err != nil {
+			return nil, err
+		}
+		return hnContent(story, text), nil
+	default:
+		if story.Title == "" {
+			return nil, fmt.Errorf("story %d has no title", id)
+		}
+		return hnContent(story, story.Title), nil
+	}
+}
+
+func (h *HackerNewsSource) item(ctx context.Context, id int) (*HNStory, error) {
+	body, err := h.http.fetch(ctx, fmt.Sprintf("%s/item/%d.json", h.http.BaseURL, id))
+	if err != nil {
 		return nil, err
 	}
+	var item HNStory
+	if err := json.Unmarshal(body, &item); err != nil {
+		return nil, fmt.Errorf("failed to decode item %d: %w", id, err)
+	}
+	return &item, nil
+}
 
-	if story.Title == "" {
-		return nil, fmt.Errorf("story has no title")
+// hnContent links to the item's own page when it has no external URL, as
+// with comments and Ask HN posts
+func hnContent(item *HNStory, text string) *Content {
+	url := item.URL
+	if url == "" {
+		url = fmt.Sprintf(hnItemURL, item.ID)
 	}
+	return &Content{Text: text, SourceURL: url, Author: item.By}
+}
 
-	return &Content{
-		Text:      story.Title,
-		SourceURL: story.URL,
-	}, nil
+// hnText turns the HTML of a comment or post into plain text, cut to a
+// typeable length
+func hnText(s string) string {
+	s = feedText(s)
+	if utf8.RuneCountInString(s) > maxParagraphRunes {
+		s = cutAtSentence(s, maxParagraphRunes)
+	}
+	return s
 }
 
 func min(a, b int) int {
@@ -79,3 +266,10 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
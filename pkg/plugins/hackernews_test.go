@@ -0,0 +1,176 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hnServer serves a fake Hacker News API with the given top stories and items
+func hnServer(t *testing.T, top string, items map[string]string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/topstories.json" {
+			fmt.Fprint(w, top)
+			return
+		}
+		item, ok := items[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, item)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestHackerNewsSource_GetContent(t *testing.T) {
+	ts := hnServer(t, `[42]`, map[string]string{
+		"/item/42.json": `{"id": 42, "title": "Show HN: A typing test", "url": "https://example.com/typing"}`,
+	})
+
+	plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+	content, err := plugin.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if content.Text != "Show HN: A typing test" {
+		t.Errorf("Text = %q, want the story title", content.Text)
+	}
+	if content.SourceURL != "https://example.com/typing" {
+		t.Errorf("SourceURL = %q, want the story URL", content.SourceURL)
+	}
+}
+
+func TestHackerNewsSource_Options(t *testing.T) {
+	ts := hnServer(t, `[1]`, map[string]string{
+		"/askstories.json": `[10, 11, 12]`,
+		"/item/10.json":    `{"id": 10, "by": "low", "score": 2, "title": "Ask HN: Too few points?", "text": "Nobody voted."}`,
+		"/item/11.json":    `{"id": 11, "by": "quiet", "score": 50, "title": "Ask HN: No body?", "kids": [21]}`,
+		"/item/12.json":    `{"id": 12, "by": "pg", "score": 80, "title": "Ask HN: What do you type?", "text": "I type &quot;code&quot;<p>and prose", "kids": [20, 21]}`,
+		"/item/20.json":    `{"id": 20, "deleted": true}`,
+		"/item/21.json":    `{"id": 21, "by": "dang", "text": "Mostly <i>comments</i> like this one &#x27;here&#x27;."}`,
+	})
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   Content
+	}{
+		{"Title", map[string]string{"list": HNListAsk, "min_score": "60"}, Content{
+			Text: "Ask HN: What do you type?", SourceURL: "https://news.ycombinator.com/item?id=12", Author: "pg",
+		}},
+		{"Text", map[string]string{"list": HNListAsk, "min_score": "10", "kind": HNKindText}, Content{
+			Text: `I type "code" and prose`, SourceURL: "https://news.ycombinator.com/item?id=12", Author: "pg",
+		}},
+		{"Comment", map[string]string{"list": HNListAsk, "min_score": "60", "kind": HNKindComment}, Content{
+			Text: "Mostly comments like this one 'here'.", SourceURL: "https://news.ycombinator.com/item?id=21", Author: "dang",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+			if err := Configure(plugin, tt.values); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 5; i++ {
+				content, err := plugin.GetContent(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if *content != tt.want {
+					t.Fatalf("content = %+v, want %+v", *content, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestHackerNewsSource_Filtered(t *testing.T) {
+	ts := hnServer(t, `[1, 2]`, map[string]string{
+		"/item/1.json": `{"id": 1, "score": 3, "title": "Small"}`,
+		"/item/2.json": `{"id": 2, "score": 5, "title": "Also small", "dead": true}`,
+	})
+
+	plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+	if err := Configure(plugin, map[string]string{"min_score": "4"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := plugin.GetContent(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "score of 4 or more") {
+		t.Errorf("err = %v, want the filter named", err)
+	}
+}
+
+func TestHackerNewsSource_StoryRange(t *testing.T) {
+	ts := hnServer(t, `[1, 2, 3]`, map[string]string{
+		"/item/1.json": `{"id": 1, "title": "First"}`,
+	})
+
+	// With a range of one only the first story may be fetched
+	plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+	plugin.Stories = 1
+	for i := 0; i < 10; i++ {
+		content, err := plugin.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Text != "First" {
+			t.Fatalf("Text = %q, want %q", content.Text, "First")
+		}
+	}
+}
+
+func TestHackerNewsSource_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		top   string
+		items map[string]string
+	}{
+		{"MalformedList", `{"not": "a list"}`, nil},
+		{"MalformedItem", `[7]`, map[string]string{"/item/7.json": `{"title": `}},
+		{"Empty", `[]`, nil},
+		{"NoTitle", `[7]`, map[string]string{"/item/7.json": `{"id": 7}`}},
+		{"HTTPError", `[7]`, nil}, // The item 404s
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := hnServer(t, tt.top, tt.items)
+
+			plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+			if _, err := plugin.GetContent(context.Background()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestHackerNewsSource_Timeout(t *testing.T) {
+	ts := slowServer(t, `[1]`)
+
+	plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL, Timeout: 20 * time.Millisecond})
+	if _, err := plugin.GetContent(context.Background()); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
+
+func TestHackerNewsSource_Cancelled(t *testing.T) {
+	ts := slowServer(t, `[1]`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	plugin := NewHackerNewsSource(HTTPOptions{BaseURL: ts.URL})
+	if _, err := plugin.GetContent(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetContent() error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,90 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds each request a network plugin makes
+	DefaultTimeout = 10 * time.Second
+	// DefaultUserAgent identifies go-racer to the sites it fetches from
+	DefaultUserAgent = "go-racer (+https://github.com/deankiwi/go-racer)"
+
+	maxBodySize = 10 << 20
+	maxPageSize = 2 << 20 // Articles are cut off here; the text comes early in the page
+)
+
+// HTTPOptions configures how a network plugin talks to its service. Zero
+// fields fall back to sensible defaults, so HTTPOptions{} is ready to use.
+type HTTPOptions struct {
+	Client    *http.Client
+	BaseURL   string // Overrides the service's address, e.g. for tests
+	Timeout   time.Duration
+	UserAgent string
+}
+
+// withDefaults fills in every unset field, using baseURL for the service address
+func (o HTTPOptions) withDefaults(baseURL string) HTTPOptions {
+	if o.Client == nil {
+		o.Client = &http.Client{}
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = baseURL
+	}
+	o.BaseURL = strings.TrimSuffix(o.BaseURL, "/")
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.UserAgent == "" {
+		o.UserAgent = DefaultUserAgent
+	}
+	return o
+}
+
+// fetch GETs url and returns the body, treating any non-2xx status as an error
+func (o HTTPOptions) fetch(ctx context.Context, url string) ([]byte, error) {
+	body, _, err := o.get(ctx, url, maxBodySize)
+	return body, err
+}
+
+// fetchPage GETs a web page for its article, returning at most maxPageSize
+// bytes of it along with its Content-Type
+func (o HTTPOptions) fetchPage(ctx context.Context, url string) ([]byte, string, error) {
+	body, contentType, err := o.get(ctx, url, maxPageSize)
+	if err != nil {
+		return nil, "", err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, "", fmt.Errorf("GET %s: %s is not a web page", url, mediaType)
+	}
+	return body, contentType, nil
+}
+
+func (o HTTPOptions) get(ctx context.Context, url string, limit int64) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", o.UserAgent)
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	return body, resp.Header.Get("Content-Type"), err
+}
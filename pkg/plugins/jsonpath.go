@@ -0,0 +1,136 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed selector in a small subset of JSONPath: $ followed by
+// .name, ['name'], [n] with negative n counting from the end, and .* or [*]
+// for every element
+type jsonPath []jsonPathStep
+
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(path string) (jsonPath, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("selector %q must start with $", path)
+	}
+
+	var steps jsonPath
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*"):
+			steps = append(steps, jsonPathStep{wildcard: true})
+			rest = rest[len(".*"):]
+		case strings.HasPrefix(rest, "[*]"):
+			steps = append(steps, jsonPathStep{wildcard: true})
+			rest = rest[len("[*]"):]
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("selector %q has an empty name", path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[1 : end+1]})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("selector %q has an unclosed [", path)
+			}
+			inner := rest[1:end]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+			} else if n, err := strconv.Atoi(inner); err == nil {
+				steps = append(steps, jsonPathStep{index: n, isIndex: true})
+			} else {
+				return nil, fmt.Errorf("selector %q: %q is not an index or quoted name", path, inner)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("selector %q: unexpected %q", path, rest)
+		}
+	}
+	return steps, nil
+}
+
+// find returns every value the selector matches in doc
+func (p jsonPath) find(doc any) []any {
+	values := []any{doc}
+	for _, step := range p {
+		var next []any
+		for _, v := range values {
+			switch v := v.(type) {
+			case map[string]any:
+				if step.wildcard {
+					keys := make([]string, 0, len(v))
+					for key := range v {
+						keys = append(keys, key)
+					}
+					sort.Strings(keys)
+					for _, key := range keys {
+						next = append(next, v[key])
+					}
+				} else if child, ok := v[step.key]; ok && !step.isIndex {
+					next = append(next, child)
+				}
+			case []any:
+				switch {
+				case step.wildcard:
+					next = append(next, v...)
+				case step.isIndex:
+					i := step.index
+					if i < 0 {
+						i += len(v)
+					}
+					if i >= 0 && i < len(v) {
+						next = append(next, v[i])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+// decodeJSON parses a response, keeping numbers as written so IDs survive
+// being put back into URLs
+func decodeJSON(data []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var doc any
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// jsonString returns a scalar as text, and false for objects and arrays
+func jsonString(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "", true
+	default:
+		return "", false
+	}
+}
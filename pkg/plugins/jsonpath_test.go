@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	doc, err := decodeJSON([]byte(`{
+		"data": {"children": [
+			{"data": {"title": "First", "id": 8863}},
+			{"data": {"title": "Second", "id": 8864}}
+		]},
+		"odd key": true
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want []any
+	}{
+		{"$", []any{doc}},
+		{"$.data.children[0].data.title", []any{"First"}},
+		{"$.data.children[-1].data.id", []any{json.Number("8864")}},
+		{"$.data.children[*].data.title", []any{"First", "Second"}},
+		{"$['odd key']", []any{true}},
+		{"$.data.children[5].data", nil},
+		{"$.missing.title", nil},
+		{"$.data.*", []any{doc.(map[string]any)["data"].(map[string]any)["children"]}},
+	}
+	for _, tt := range tests {
+		path, err := parseJSONPath(tt.path)
+		if err != nil {
+			t.Errorf("parseJSONPath(%q): %v", tt.path, err)
+			continue
+		}
+		if got := path.find(doc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s found %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSONPath_Invalid(t *testing.T) {
+	for _, path := range []string{"", "title", "$.", "$..title", "$[0", "$[first]", "$title"} {
+		if _, err := parseJSONPath(path); err == nil {
+			t.Errorf("parseJSONPath(%q) succeeded, want an error", path)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultCodeDir        = "."
+	defaultCodeExtensions = "go,c,h,cc,cpp,hpp,cs,java,kt,scala,swift,rs,js,jsx,ts,tsx,php,py"
+
+	maxCodeFiles    = 10000   // Files considered in one directory tree
+	maxCodeFileSize = 1 << 20 // Larger files are almost always generated data
+	maxCodeAttempts = 20      // Files tried before giving up on finding a block
+)
+
+// vendorDirs hold other people's code, which is not what the plugin is for
+var vendorDirs = map[string]bool{
+	"vendor":           true,
+	"node_modules":     true,
+	"third_party":      true,
+	"bower_components": true,
+	"Pods":             true,
+}
+
+func init() {
+	Register(Registration{
+		Name:        "local-code",
+		Aliases:     []string{"code"},
+		Description: "Functions from source code on this computer",
+		Category:    "code",
+		Online:      false,
+		Factory:     func() ContentSource { return NewLocalCodeSource() },
+	})
+}
+
+// LocalCodeSource picks functions and blocks from the files of a local
+// directory, such as a git checkout
+type LocalCodeSource struct {
+	Dir        string
+	Extensions []string // File extensions to read, with the leading dot
+
+	mu    sync.Mutex
+	files []string // Found on the first read, relative to Dir
+}
+
+func NewLocalCodeSource() *LocalCodeSource {
+	return &LocalCodeSource{
+		Dir:        defaultCodeDir,
+		Extensions: parseExtensions(defaultCodeExtensions),
+	}
+}
+
+func (l *LocalCodeSource) Name() string {
+	return "Local Code"
+}
+
+func (l *LocalCodeSource) Description() string {
+	return "Types out functions from a directory of source code on this computer"
+}
+
+func (l *LocalCodeSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "dir",
+			Label:       "Directory",
+			Description: "Directory or git checkout to pick code from",
+			Type:        SettingString,
+			Default:     defaultCodeDir,
+		},
+		{
+			Key:         "extensions",
+			Label:       "File types",
+			Description: "Comma-separated extensions of the files to read",
+			Type:        SettingList,
+			Default:     defaultCodeExtensions,
+		},
+	}
+}
+
+func (l *LocalCodeSource) Configure(values map[string]string) error {
+	dir := strings.TrimSpace(values["dir"])
+	if dir == "" {
+		return errors.New("directory is empty")
+	}
+	dir, err := expandHome(dir)
+	if err != nil {
+		return err
+	}
+	exts := parseExtensions(values["extensions"])
+	if len(exts) == 0 {
+		return errors.New("no file types configured")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Dir, l.Extensions, l.files = dir, exts, nil
+	return nil
+}
+
+// parseExtensions turns "go, .py" into [".go", ".py"]
+func parseExtensions(value string) []string {
+	var exts []string
+	for _, ext := range ParseList(value) {
+		exts = append(exts, "."+strings.TrimPrefix(strings.ToLower(ext), "."))
+	}
+	return exts
+}
+
+// GetContent picks a random file and returns one of its functions or blocks
+func (l *LocalCodeSource) GetContent(ctx context.Context) (*Content, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.files == nil {
+		files, err := l.scan(ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.files = files
+	}
+	if len(l.files) == 0 {
+		return nil, fmt.Errorf("no source files found in %s", l.Dir)
+	}
+
+	for i, n := range rand.Perm(len(l.files)) {
+		if i == maxCodeAttempts {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(l.Dir, l.files[n])
+		src, err := os.ReadFile(path)
+		if err != nil || isGenerated(src) {
+			continue
+		}
+		blocks, err := extractBlocks(path, src)
+		if err != nil || len(blocks) == 0 {
+			continue
+		}
+
+		block := blocks[rand.Intn(len(blocks))]
+		return &Content{
+			Text:      block.text,
+			SourceURL: fileURL(path, block.start),
+			Code:      true,
+		}, nil
+	}
+	return nil, fmt.Errorf("no functions of at most %d lines found in %s", maxFuncLines, l.Dir)
+}
+
+// scan lists the source files under Dir, leaving out hidden, vendored,
+// generated and git-ignored ones
+func (l *LocalCodeSource) scan(ctx context.Context) ([]string, error) {
+	info, err := os.Stat(l.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", l.Dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", l.Dir)
+	}
+
+	var ignore gitignore
+	files := []string{}
+	err = filepath.WalkDir(l.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what cannot be read rather than fail
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(l.Dir, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." {
+				if strings.HasPrefix(d.Name(), ".") || vendorDirs[d.Name()] || ignore.ignored(rel, true) {
+					return filepath.SkipDir
+				}
+			}
+			if data, err := os.ReadFile(filepath.Join(path, ".gitignore")); err == nil {
+				base := rel
+				if base == "." {
+					base = ""
+				}
+				ignore.add(base, string(data))
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || !l.wants(d.Name()) || ignore.ignored(rel, false) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxCodeFileSize {
+			return nil
+		}
+		files = append(files, filepath.FromSlash(rel))
+		if len(files) == maxCodeFiles {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// wants reports whether a file name has one of the configured extensions
+// and does not look generated
+func (l *LocalCodeSource) wants(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range []string{".min.js", ".pb.go", "_generated.go", ".generated.cs", ".g.dart"} {
+		if strings.HasSuffix(lower, suffix) {
+			return false
+		}
+	}
+	ext := filepath.Ext(lower)
+	for _, want := range l.Extensions {
+		if ext == want {
+			return true
+		}
+	}
+	return false
+}
+
+// isGenerated looks for the markers code generators leave near the top of
+// their output, such as Go's "Code generated ... DO NOT EDIT."
+func isGenerated(src []byte) bool {
+	head := src
+	for i, n := 0, 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			if n++; n == 10 {
+				head = src[:i]
+				break
+			}
+		}
+	}
+	head = bytes.ToLower(head)
+	for _, marker := range []string{"code generated", "do not edit", "@generated", "autogenerated", "auto-generated"} {
+		if bytes.Contains(head, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileURL links to a line of a local file
+func fileURL(path string, line int) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letters
+	}
+	u := url.URL{Scheme: "file", Path: path, Fragment: fmt.Sprintf("L%d", line)}
+	return u.String()
+}
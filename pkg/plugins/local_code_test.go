@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files, keyed by slash-separated path, under a temp dir
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newTestLocalCodeSource(t *testing.T, dir string) *LocalCodeSource {
+	t.Helper()
+	l := NewLocalCodeSource()
+	if err := Configure(l, map[string]string{"dir": dir}); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestLocalCodeSource_GetContent(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		".gitignore":           "ignored/\n*.tmp.go\n",
+		"main.go":              "package main\n\nfunc main() {\n\trun()\n}\n",
+		"ignored/old.go":       "package old\n\nfunc Old() {\n}\n",
+		"scratch.tmp.go":       "package main\n\nfunc Scratch() {\n}\n",
+		"vendor/lib/lib.go":    "package lib\n\nfunc Lib() {\n}\n",
+		"node_modules/x/x.js":  "function x() {\n}\n",
+		".hidden/h.go":         "package h\n\nfunc H() {\n}\n",
+		"api/api.pb.go":        "package api\n\nfunc Pb() {\n}\n",
+		"gen/gen.go":           "// Code generated by stringer. DO NOT EDIT.\n\npackage gen\n\nfunc Gen() {\n}\n",
+		"README.md":            "# Not code {\n}\n",
+		"sub/.gitignore":       "local.go\n",
+		"sub/local.go":         "package sub\n\nfunc Local() {\n}\n",
+		"sub/deeper/nested.go": "package deeper\n\nfunc Nested() {\n}\n",
+	})
+	l := newTestLocalCodeSource(t, dir)
+
+	// Only main.go and sub/deeper/nested.go are eligible
+	want := map[string]string{
+		"func main() {\n\trun()\n}": fileURL(filepath.Join(dir, "main.go"), 3),
+		"func Nested() {\n}":        fileURL(filepath.Join(dir, "sub", "deeper", "nested.go"), 3),
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 50 && len(seen) < len(want); i++ {
+		content, err := l.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		url, ok := want[content.Text]
+		if !ok {
+			t.Fatalf("unexpected text %q", content.Text)
+		}
+		if content.SourceURL != url {
+			t.Errorf("SourceURL = %q, want %q", content.SourceURL, url)
+		}
+		seen[content.Text] = true
+	}
+	if len(seen) != len(want) {
+		t.Errorf("saw %d of %d functions", len(seen), len(want))
+	}
+}
+
+func TestLocalCodeSource_Extensions(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.go": "package main\n\nfunc main() {\n}\n",
+		"app.py":  "def main():\n    pass\n",
+	})
+	l := NewLocalCodeSource()
+	if err := Configure(l, map[string]string{"dir": dir, "extensions": ".PY"}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := l.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "def main():\n    pass" {
+		t.Errorf("Text = %q, want the Python function", content.Text)
+	}
+}
+
+func TestLocalCodeSource_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		dir  func(t *testing.T) string
+	}{
+		{"Missing", func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing") }},
+		{"File", func(t *testing.T) string {
+			return filepath.Join(writeTree(t, map[string]string{"main.go": "package main\n"}), "main.go")
+		}},
+		{"NoSourceFiles", func(t *testing.T) string { return writeTree(t, map[string]string{"notes.txt": "hello"}) }},
+		{"NoFunctions", func(t *testing.T) string {
+			return writeTree(t, map[string]string{"consts.go": "package consts\n\nconst A = 1\n"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLocalCodeSource(t, tt.dir(t))
+			if _, err := l.GetContent(context.Background()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	if got, want := fileURL("/src/my app/main.go", 12), "file:///src/my%20app/main.go#L12"; got != want {
+		t.Errorf("fileURL() = %q, want %q", got, want)
+	}
+}
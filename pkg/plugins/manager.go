@@ -1,20 +1,99 @@
 package plugins
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
 
+// Registration describes a plugin and how to create it. Plugins register
+// themselves from an init function.
+type Registration struct {
+	Name        string   // Used with -plugin and stored in the config
+	Aliases     []string // Other names accepted for -plugin
+	Description string
+	Category    string // e.g. "news" or "code"
+	Language    string // Language of the text, e.g. "en", "es" or "go" for Go code
+	Online      bool   // Needs network access
+	Factory     func() ContentSource
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*Registration) // Keyed by name and every alias
+)
+
+// Register makes a plugin available by name. It panics if the name or an
+// alias is already taken, as that is a programming error.
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if r.Name == "" || r.Factory == nil {
+		panic("plugins: Register needs a name and a factory")
+	}
+	for _, name := range append([]string{r.Name}, r.Aliases...) {
+		if _, dup := registry[name]; dup {
+			panic("plugins: Register called twice for " + name)
+		}
+	}
+	for _, name := range append([]string{r.Name}, r.Aliases...) {
+		registry[name] = &r
+	}
+}
+
+// Lookup finds a registration by name or alias
+func Lookup(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, ok := registry[name]
+	if !ok {
+		return Registration{}, false
+	}
+	return *r, true
+}
+
+// Registrations returns every registered plugin, sorted by name
+func Registrations() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var regs []Registration
+	for name, r := range registry {
+		if name == r.Name {
+			regs = append(regs, *r)
+		}
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i].Name < regs[j].Name })
+	return regs
+}
+
+// GetPlugin creates the plugin registered under name or one of its aliases
 func GetPlugin(name string) (ContentSource, error) {
-	switch name {
-	case "hn":
-		return NewHackerNewsSource(), nil
-	case "github":
-		return NewGitHubSource(), nil
-	case "spanish-news":
-		return NewSpanishNewsSource(), nil
-	default:
+	r, ok := Lookup(name)
+	if !ok {
 		return nil, fmt.Errorf("unknown plugin: %s", name)
 	}
+	return r.Factory(), nil
 }
 
+// ListPlugins returns the names of every registered plugin
 func ListPlugins() []string {
-	return []string{"hn", "github", "spanish-news"}
+	var names []string
+	for _, r := range Registrations() {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// GetConfiguredPlugin returns the named plugin with its stored options applied
+func GetConfiguredPlugin(name string, options map[string]string) (ContentSource, error) {
+	src, err := GetPlugin(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := Configure(src, options); err != nil {
+		return nil, fmt.Errorf("failed to configure %s: %w", name, err)
+	}
+	return src, nil
 }
@@ -0,0 +1,45 @@
+package plugins
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	names := ListPlugins()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("ListPlugins() = %q, want sorted names", names)
+	}
+
+	// Every listed plugin can be created, so the list cannot drift from the registry
+	for _, name := range names {
+		src, err := GetPlugin(name)
+		if err != nil || src == nil {
+			t.Errorf("GetPlugin(%q) = %v, %v", name, src, err)
+		}
+	}
+}
+
+func TestLookupAlias(t *testing.T) {
+	r, ok := Lookup("hackernews")
+	if !ok || r.Name != "hn" {
+		t.Errorf("Lookup(hackernews) = %q, %v, want hn", r.Name, ok)
+	}
+
+	if _, err := GetPlugin("no-such-plugin"); err == nil {
+		t.Error("expected an error for an unknown plugin")
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Register to panic on a taken alias")
+		}
+	}()
+	Register(Registration{
+		Name:    "hn-again",
+		Aliases: []string{"hn"},
+		Factory: func() ContentSource { return NewHackerNewsSource(HTTPOptions{}) },
+	})
+}
@@ -0,0 +1,352 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-racer/pkg/config"
+)
+
+const (
+	defaultNotesDir = "."
+
+	// Link kinds for SourceURL
+	LinksAuto     = "auto" // obsidian:// inside an Obsidian vault, file:// elsewhere
+	LinksFile     = "file"
+	LinksObsidian = "obsidian"
+
+	maxNoteAttempts = 20                  // Notes tried before giving up on finding a paragraph
+	maxNoteHistory  = 500                 // Picks remembered for favouring other notes
+	noteRestPeriod  = 30 * 24 * time.Hour // Notes picked longer ago are as likely as new ones
+	noteHistoryFile = "markdown-history.json"
+)
+
+func init() {
+	Register(Registration{
+		Name:        "markdown",
+		Aliases:     []string{"obsidian", "notes"},
+		Description: "Paragraphs from a folder of Markdown notes",
+		Category:    "notes",
+		Online:      false,
+		Factory:     func() ContentSource { return NewMarkdownSource() },
+	})
+}
+
+// MarkdownSource picks paragraphs from a directory of Markdown notes, such
+// as an Obsidian vault. Notes picked recently are less likely to come up.
+type MarkdownSource struct {
+	Dir     string
+	Tags    []string // Only notes with one of these tags; all notes if empty
+	Folders []string // Only notes in these folders, relative to Dir; all if empty
+	Links   string   // LinksAuto, LinksFile or LinksObsidian
+
+	// HistoryPath is where picks are remembered between runs. Empty keeps
+	// them in memory only.
+	HistoryPath string
+
+	mu      sync.Mutex
+	notes   []string             // Found on the first read, relative to Dir
+	history map[string]time.Time // When each note was picked, by absolute path
+}
+
+func NewMarkdownSource() *MarkdownSource {
+	m := &MarkdownSource{
+		Dir:   defaultNotesDir,
+		Links: LinksAuto,
+	}
+	if dir, err := config.DataDir(); err == nil {
+		m.HistoryPath = filepath.Join(dir, noteHistoryFile)
+	}
+	return m
+}
+
+func (m *MarkdownSource) Name() string {
+	return "Markdown Notes"
+}
+
+func (m *MarkdownSource) Description() string {
+	return "Types out paragraphs from your Markdown notes or Obsidian vault"
+}
+
+func (m *MarkdownSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "dir",
+			Label:       "Notes directory",
+			Description: "Directory of Markdown notes, such as an Obsidian vault",
+			Type:        SettingString,
+			Default:     defaultNotesDir,
+		},
+		{
+			Key:         "tags",
+			Label:       "Tags",
+			Description: "Comma-separated tags; only notes with one of them are used",
+			Type:        SettingList,
+		},
+		{
+			Key:         "folders",
+			Label:       "Folders",
+			Description: "Comma-separated folders within the directory to pick notes from",
+			Type:        SettingList,
+		},
+		{
+			Key:         "links",
+			Label:       "Links",
+			Description: "How notes are opened from the results screen",
+			Type:        SettingEnum,
+			Default:     LinksAuto,
+			Options:     []string{LinksAuto, LinksFile, LinksObsidian},
+		},
+	}
+}
+
+func (m *MarkdownSource) Configure(values map[string]string) error {
+	dir := strings.TrimSpace(values["dir"])
+	if dir == "" {
+		return errors.New("directory is empty")
+	}
+	dir, err := expandHome(dir)
+	if err != nil {
+		return err
+	}
+
+	var folders []string
+	for _, folder := range ParseList(values["folders"]) {
+		folders = append(folders, strings.Trim(filepath.ToSlash(folder), "/"))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Dir, m.Tags, m.Folders, m.Links = dir, ParseList(values["tags"]), folders, values["links"]
+	m.notes = nil
+	return nil
+}
+
+// GetContent picks a note, favouring ones not picked recently, and returns
+// one of its paragraphs as plain text
+func (m *MarkdownSource) GetContent(ctx context.Context) (*Content, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.notes == nil {
+		notes, err := m.scan(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m.notes = notes
+	}
+	if len(m.notes) == 0 {
+		return nil, fmt.Errorf("no matching notes found in %s", m.Dir)
+	}
+	m.loadHistory()
+
+	candidates := append([]string(nil), m.notes...)
+	for attempt := 0; attempt < maxNoteAttempts && len(candidates) > 0; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		i := m.pick(candidates)
+		note := candidates[i]
+		candidates = append(candidates[:i], candidates[i+1:]...)
+
+		src, err := os.ReadFile(filepath.Join(m.Dir, note))
+		if err != nil {
+			continue
+		}
+		paragraphs := markdownParagraphs(string(src))
+		if len(paragraphs) == 0 {
+			continue
+		}
+
+		p := paragraphs[rand.Intn(len(paragraphs))]
+		m.remember(note)
+		return &Content{
+			Text:      p.text,
+			SourceURL: m.noteURL(note, p.line),
+			Author:    strings.TrimSuffix(filepath.Base(note), filepath.Ext(note)),
+		}, nil
+	}
+	return nil, fmt.Errorf("no paragraphs of at least %d words found in %s", minParagraphWords, m.Dir)
+}
+
+// scan lists the notes under Dir that match the folder and tag filters
+func (m *MarkdownSource) scan(ctx context.Context) ([]string, error) {
+	info, err := os.Stat(m.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", m.Dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", m.Dir)
+	}
+
+	notes := []string{}
+	err = filepath.WalkDir(m.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what cannot be read rather than fail
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skips .obsidian, .trash and .git
+			if path != m.Dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(m.Dir, path)
+		if err != nil || !m.inFolders(filepath.ToSlash(rel)) {
+			return nil
+		}
+		if len(m.Tags) > 0 {
+			src, err := os.ReadFile(path)
+			if err != nil || !m.tagged(noteTags(string(src))) {
+				return nil
+			}
+		}
+		notes = append(notes, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (m *MarkdownSource) inFolders(rel string) bool {
+	if len(m.Folders) == 0 {
+		return true
+	}
+	for _, folder := range m.Folders {
+		if strings.HasPrefix(rel, folder+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MarkdownSource) tagged(tags []string) bool {
+	for _, want := range m.Tags {
+		if hasTag(tags, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// pick chooses a note at random, weighted so that notes picked recently are
+// unlikely to come up again before the others have had a turn
+func (m *MarkdownSource) pick(notes []string) int {
+	weights := make([]float64, len(notes))
+	total := 0.0
+	for i, note := range notes {
+		weights[i] = 1
+		if picked, ok := m.history[m.notePath(note)]; ok {
+			if age := time.Since(picked); age < noteRestPeriod {
+				weights[i] = 0.05 + 0.95*float64(age)/float64(noteRestPeriod)
+			}
+		}
+		total += weights[i]
+	}
+
+	r := rand.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(notes) - 1
+}
+
+// noteURL links to a line of a note, opening it in Obsidian when asked or
+// when Dir is a vault
+func (m *MarkdownSource) noteURL(note string, line int) string {
+	links := m.Links
+	if links == LinksAuto {
+		links = LinksFile
+		if info, err := os.Stat(filepath.Join(m.Dir, ".obsidian")); err == nil && info.IsDir() {
+			links = LinksObsidian
+		}
+	}
+	if links != LinksObsidian {
+		return fileURL(filepath.Join(m.Dir, note), line)
+	}
+
+	vault := m.Dir
+	if abs, err := filepath.Abs(vault); err == nil {
+		vault = abs
+	}
+	file := strings.TrimSuffix(filepath.ToSlash(note), filepath.Ext(note))
+	return "obsidian://open?vault=" + obsidianEscape(filepath.Base(vault)) + "&file=" + obsidianEscape(file)
+}
+
+// obsidianEscape escapes a query value for an obsidian:// link. Obsidian
+// doesn't read + as a space, so spaces are written as %20; a literal + is
+// already %2B by then.
+func obsidianEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// notePath identifies a note in the history, which is shared by every
+// directory the plugin is pointed at
+func (m *MarkdownSource) notePath(note string) string {
+	path := filepath.Join(m.Dir, note)
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// loadHistory reads the picks of earlier runs once. Callers must hold m.mu.
+func (m *MarkdownSource) loadHistory() {
+	if m.history != nil {
+		return
+	}
+	m.history = make(map[string]time.Time)
+	if m.HistoryPath == "" {
+		return
+	}
+	if data, err := os.ReadFile(m.HistoryPath); err == nil {
+		_ = json.Unmarshal(data, &m.history)
+	}
+}
+
+// remember records a pick, dropping the oldest once there are too many.
+// Callers must hold m.mu.
+func (m *MarkdownSource) remember(note string) {
+	m.history[m.notePath(note)] = time.Now()
+	for len(m.history) > maxNoteHistory {
+		oldest := ""
+		for n, t := range m.history {
+			if oldest == "" || t.Before(m.history[oldest]) {
+				oldest = n
+			}
+		}
+		delete(m.history, oldest)
+	}
+
+	if m.HistoryPath == "" {
+		return
+	}
+	// Failures only cost the weighting, so they are ignored
+	if data, err := json.Marshal(m.history); err == nil {
+		_ = config.WriteFileAtomic(m.HistoryPath, data, 0644)
+	}
+}
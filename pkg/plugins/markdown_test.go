@@ -0,0 +1,185 @@
+package plugins
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const notePara = "This paragraph is long enough to be picked for typing."
+
+func newTestMarkdownSource(t *testing.T, values map[string]string) *MarkdownSource {
+	t.Helper()
+	m := NewMarkdownSource()
+	m.HistoryPath = filepath.Join(t.TempDir(), noteHistoryFile)
+	if err := Configure(m, values); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMarkdownSource_GetContent(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"Daily/today.md":        "# Today\n\n" + notePara + "\n",
+		".obsidian/app.json":    "{}",
+		".trash/deleted.md":     "Deleted notes should never be picked for typing again.\n",
+		"attachments/image.png": "png",
+	})
+	m := newTestMarkdownSource(t, map[string]string{"dir": dir})
+
+	content, err := m.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != notePara {
+		t.Errorf("Text = %q, want %q", content.Text, notePara)
+	}
+	if content.Author != "today" {
+		t.Errorf("Author = %q, want the note title", content.Author)
+	}
+	want := "obsidian://open?vault=" + filepath.Base(dir) + "&file=Daily%2Ftoday"
+	if content.SourceURL != want {
+		t.Errorf("SourceURL = %q, want %q", content.SourceURL, want)
+	}
+}
+
+func TestMarkdownSource_ObsidianLinkEscaping(t *testing.T) {
+	root := writeTree(t, map[string]string{
+		"a=b & c+d/Q&A notes.md":       "# Q&A\n\n" + notePara + "\n",
+		"a=b & c+d/.obsidian/app.json": "{}",
+	})
+	m := newTestMarkdownSource(t, map[string]string{"dir": filepath.Join(root, "a=b & c+d")})
+
+	content, err := m.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "obsidian://open?vault=a%3Db%20%26%20c%2Bd&file=Q%26A%20notes"
+	if content.SourceURL != want {
+		t.Errorf("SourceURL = %q, want %q", content.SourceURL, want)
+	}
+	u, err := url.Parse(content.SourceURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := u.Query(); len(q) != 2 || q.Get("vault") != "a=b & c+d" || q.Get("file") != "Q&A notes" {
+		t.Errorf("query = %v, want just the vault and file", q)
+	}
+}
+
+func TestMarkdownSource_FileLinks(t *testing.T) {
+	dir := writeTree(t, map[string]string{"note.md": "# Title\n\n" + notePara + "\n"})
+	m := newTestMarkdownSource(t, map[string]string{"dir": dir})
+
+	content, err := m.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fileURL(filepath.Join(dir, "note.md"), 3); content.SourceURL != want {
+		t.Errorf("SourceURL = %q, want %q", content.SourceURL, want)
+	}
+}
+
+func TestMarkdownSource_Filters(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"work/plan.md":     "---\ntags: [work]\n---\nThe plan for work is written down in this paragraph here.\n",
+		"work/ideas.md":    "An untagged idea about work that should not be picked here.\n",
+		"home/recipe.md":   "A #work/recipe note that lives outside the chosen folder somehow.\n",
+		"home/shopping.md": "Buy milk, eggs, bread, butter and some cheese from the shop.\n",
+	})
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   []string // Titles that may be picked
+	}{
+		{"Tags", map[string]string{"dir": dir, "tags": "work"}, []string{"plan", "recipe"}},
+		{"Folders", map[string]string{"dir": dir, "folders": "home/"}, []string{"recipe", "shopping"}},
+		{"Both", map[string]string{"dir": dir, "tags": "#Work", "folders": "work"}, []string{"plan"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMarkdownSource(t, tt.values)
+			seen := make(map[string]bool)
+			for i := 0; i < 30; i++ {
+				content, err := m.GetContent(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				seen[content.Author] = true
+			}
+			for author := range seen {
+				if !slices.Contains(tt.want, author) {
+					t.Errorf("picked %q, want only %v", author, tt.want)
+				}
+			}
+			if len(seen) != len(tt.want) {
+				t.Errorf("picked %d different notes, want %d", len(seen), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestMarkdownSource_FavoursUntypedNotes(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"old.md": "The note that was typed a moment ago and should rest now.\n",
+		"new.md": "The note that has never been typed and should come up first.\n",
+	})
+	m := newTestMarkdownSource(t, map[string]string{"dir": dir})
+	m.history = map[string]time.Time{m.notePath("old.md"): time.Now()}
+
+	newPicks := 0
+	for i := 0; i < 100; i++ {
+		if m.pick([]string{"old.md", "new.md"}) == 1 {
+			newPicks++
+		}
+	}
+	if newPicks < 80 {
+		t.Errorf("untyped note picked %d times out of 100, want it strongly favoured", newPicks)
+	}
+
+	// Picks are remembered across runs
+	content, err := m.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened := NewMarkdownSource()
+	reopened.HistoryPath = m.HistoryPath
+	reopened.loadHistory()
+	if _, ok := reopened.history[m.notePath(content.Author+".md")]; !ok {
+		t.Errorf("history after reopening = %v, want the note just picked", reopened.history)
+	}
+}
+
+func TestMarkdownSource_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		values func(t *testing.T) map[string]string
+	}{
+		{"Missing", func(t *testing.T) map[string]string {
+			return map[string]string{"dir": filepath.Join(t.TempDir(), "missing")}
+		}},
+		{"NoNotes", func(t *testing.T) map[string]string {
+			return map[string]string{"dir": writeTree(t, map[string]string{"a.txt": notePara})}
+		}},
+		{"NoMatchingTag", func(t *testing.T) map[string]string {
+			return map[string]string{"dir": writeTree(t, map[string]string{"a.md": notePara}), "tags": "nope"}
+		}},
+		{"OnlyShortParagraphs", func(t *testing.T) map[string]string {
+			return map[string]string{"dir": writeTree(t, map[string]string{"a.md": "# Title\n\n- one\n- two\n"})}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMarkdownSource(t, tt.values(t))
+			if _, err := m.GetContent(context.Background()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package plugins
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	minParagraphWords = 8   // Shorter paragraphs are usually labels or fragments
+	maxParagraphRunes = 600 // Longer paragraphs are cut at a sentence end
+)
+
+var (
+	mdComment      = regexp.MustCompile(`(?s)<!--.*?-->|%%.*?%%`)
+	mdEmbed        = regexp.MustCompile(`!\[\[[^\]]*\]\]|!\[[^\]]*\]\([^)]*\)`)
+	mdWikiLink     = regexp.MustCompile(`\[\[([^\]|#]*)(?:#[^\]|]*)?(?:\|([^\]]*))?\]\]`)
+	mdLink         = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	mdFootnote     = regexp.MustCompile(`\[\^[^\]]*\]`)
+	mdAutolink     = regexp.MustCompile(`<(?:https?|mailto):[^>]*>`)
+	mdHTMLTag      = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	mdInlineCode   = regexp.MustCompile("`([^`]*)`")
+	mdEmphasis     = regexp.MustCompile(`(\*\*|__|~~|==)(.+?)(\*\*|__|~~|==)`)
+	mdItalic       = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\s](?:[^*_]*[^*_\s])?)[*_]`)
+	mdTag          = regexp.MustCompile(`(^|\s)#([\p{L}\p{N}_/-]*[\p{L}_/-][\p{L}\p{N}_/-]*)`)
+	mdHeading      = regexp.MustCompile(`^#{1,6}(\s|$)`)
+	mdListMarker   = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[.\]\s+)?`)
+	mdCallout      = regexp.MustCompile(`^\[![^\]]*\][+-]?\s*`)
+	mdRule         = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdLinkRef      = regexp.MustCompile(`^\s*\[[^\]]+\]:\s`)
+	sentenceEnding = regexp.MustCompile(`[.!?]["')\]]?\s`)
+)
+
+// mdParagraph is a paragraph of a note as plain text
+type mdParagraph struct {
+	text string
+	line int // 1-based line the paragraph starts on
+}
+
+// splitFrontMatter separates a leading YAML block from the body of a note.
+// offset is the number of lines the front matter took up.
+func splitFrontMatter(src string) (frontMatter, body string, offset int) {
+	if !strings.HasPrefix(src, "---\n") && !strings.HasPrefix(src, "---\r\n") {
+		return "", src, 0
+	}
+	lines := strings.Split(src, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[1:i], "\n"), strings.Join(lines[i+1:], "\n"), i + 1
+		}
+	}
+	return "", src, 0
+}
+
+// noteTags collects the tags of a note from its front matter ("tags: [a, b]"
+// or a YAML list) and from #tags in its body, lowercased and without "#"
+func noteTags(src string) []string {
+	frontMatter, body, _ := splitFrontMatter(src)
+
+	var tags []string
+	inTags := false
+	for _, line := range strings.Split(frontMatter, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if key, value, ok := strings.Cut(trimmed, ":"); ok && !strings.HasPrefix(trimmed, "-") {
+			inTags = key == "tags" || key == "tag"
+			if inTags {
+				value = strings.Trim(strings.TrimSpace(value), "[]")
+				tags = append(tags, strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })...)
+			}
+			continue
+		}
+		if inTags && strings.HasPrefix(trimmed, "-") {
+			tags = append(tags, strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+		}
+	}
+
+	for _, match := range mdTag.FindAllStringSubmatch(stripCode(body), -1) {
+		tags = append(tags, match[2])
+	}
+
+	for i, tag := range tags {
+		tags[i] = strings.ToLower(strings.Trim(tag, `#"' `))
+	}
+	return tags
+}
+
+// hasTag reports whether tags include want or a tag nested below it
+func hasTag(tags []string, want string) bool {
+	want = strings.ToLower(strings.TrimPrefix(want, "#"))
+	for _, tag := range tags {
+		if tag == want || strings.HasPrefix(tag, want+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// stripCode blanks out fenced code blocks, keeping the line count
+func stripCode(src string) string {
+	lines := strings.Split(src, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			fence = trimmed[:3]
+			lines[i] = ""
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownParagraphs turns a note into plain-text paragraphs worth typing.
+// Front matter, code, headings, tables and markup are dropped; links keep
+// their text.
+func markdownParagraphs(src string) []mdParagraph {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	_, body, offset := splitFrontMatter(src)
+	body = stripCode(body)
+	body = mdComment.ReplaceAllStringFunc(body, func(s string) string {
+		return strings.Repeat("\n", strings.Count(s, "\n")) // Keep line numbers
+	})
+
+	var paragraphs []mdParagraph
+	var current []string
+	start := 0
+	flush := func() {
+		if len(current) > 0 {
+			if text, ok := paragraphText(strings.Join(current, " ")); ok {
+				paragraphs = append(paragraphs, mdParagraph{text: text, line: start + offset + 1})
+			}
+		}
+		current = nil
+	}
+
+	inQuote := false
+	for i, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// A quote starts or ends a paragraph of its own
+		quote := strings.HasPrefix(trimmed, ">")
+		if quote != inQuote {
+			flush()
+			inQuote = quote
+		}
+		for strings.HasPrefix(trimmed, ">") {
+			trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+		}
+		trimmed = mdCallout.ReplaceAllString(trimmed, "")
+
+		switch {
+		case trimmed == "", mdHeading.MatchString(trimmed),
+			strings.HasPrefix(trimmed, "|"), mdRule.MatchString(trimmed), mdLinkRef.MatchString(trimmed):
+			// Blank lines, headings, tables and rules end a paragraph
+			flush()
+			continue
+		case mdListMarker.MatchString(trimmed):
+			// Every list item stands on its own
+			flush()
+			trimmed = mdListMarker.ReplaceAllString(trimmed, "")
+		}
+		if len(current) == 0 {
+			start = i
+		}
+		current = append(current, trimmed)
+	}
+	flush()
+	return paragraphs
+}
+
+// paragraphText strips inline markup and reports whether what is left is
+// long enough to type
+func paragraphText(s string) (string, bool) {
+	s = mdEmbed.ReplaceAllString(s, "")
+	s = mdWikiLink.ReplaceAllStringFunc(s, func(link string) string {
+		m := mdWikiLink.FindStringSubmatch(link)
+		if m[2] != "" {
+			return m[2]
+		}
+		return m[1]
+	})
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdFootnote.ReplaceAllString(s, "")
+	s = mdAutolink.ReplaceAllString(s, "")
+	s = mdHTMLTag.ReplaceAllString(s, "")
+	s = mdInlineCode.ReplaceAllString(s, "$1")
+	for mdEmphasis.MatchString(s) {
+		s = mdEmphasis.ReplaceAllString(s, "$2")
+	}
+	s = mdItalic.ReplaceAllString(s, "$1$2")
+	s = mdTag.ReplaceAllString(s, "$1")
+	s = strings.Join(strings.Fields(s), " ")
+
+	if utf8.RuneCountInString(s) > maxParagraphRunes {
+		s = cutAtSentence(s, maxParagraphRunes)
+	}
+	return s, len(strings.Fields(s)) >= minParagraphWords
+}
+
+// cutAtSentence shortens s to whole sentences of at most n runes, or to
+// whole words if no sentence ends early enough
+func cutAtSentence(s string, n int) string {
+	limit := len(string([]rune(s)[:n]))
+	cut := -1
+	for _, loc := range sentenceEnding.FindAllStringIndex(s[:limit], -1) {
+		cut = loc[1] - 1
+	}
+	if cut <= 0 {
+		cut = strings.LastIndexByte(s[:limit], ' ')
+	}
+	if cut <= 0 {
+		cut = limit
+	}
+	return strings.TrimSpace(s[:cut])
+}
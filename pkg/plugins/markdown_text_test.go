@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownParagraphs(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []mdParagraph
+	}{
+		{
+			name: "FrontMatterAndHeadings",
+			src:  "---\ntitle: Notes\ntags: [a]\n---\n# Heading\n\nThe quick brown fox jumps over the lazy dog.\n",
+			want: []mdParagraph{{text: "The quick brown fox jumps over the lazy dog.", line: 7}},
+		},
+		{
+			name: "Formatting",
+			src:  "Some **bold**, _italic_, ~~struck~~, ==marked== and `code` words in snake_case_names.\n",
+			want: []mdParagraph{{text: "Some bold, italic, struck, marked and code words in snake_case_names.", line: 1}},
+		},
+		{
+			name: "Links",
+			src:  "See [the docs](https://example.com) and [[Other Note]] or [[Other Note#Part|this one]] plus ![[image.png]] ![alt](pic.png) now.\n",
+			want: []mdParagraph{{text: "See the docs and Other Note or this one plus now.", line: 1}},
+		},
+		{
+			name: "CodeFencesAndTables",
+			src:  "```go\nfunc main() {}\nfunc other() {}\n```\n\n| a | b |\n|---|---|\n\nAfter the code there is a paragraph long enough to type.\n",
+			want: []mdParagraph{{text: "After the code there is a paragraph long enough to type.", line: 9}},
+		},
+		{
+			name: "ListsQuotesAndTags",
+			src:  "- [ ] A task item that is long enough to be typed out #todo\n> [!note] A callout with enough words in it to be kept\n> and a second line\n\nshort one\n",
+			want: []mdParagraph{
+				{text: "A task item that is long enough to be typed out", line: 1},
+				{text: "A callout with enough words in it to be kept and a second line", line: 2},
+			},
+		},
+		{
+			name: "Comments",
+			src:  "<!-- hidden\ncomment -->\n%%private%% Words that remain after the comments are taken out.\n",
+			want: []mdParagraph{{text: "Words that remain after the comments are taken out.", line: 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownParagraphs(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("markdownParagraphs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownParagraphs_CutsLongParagraphs(t *testing.T) {
+	src := strings.Repeat("This sentence has a handful of words. ", 40)
+	got := markdownParagraphs(src)
+	if len(got) != 1 {
+		t.Fatalf("got %d paragraphs, want 1", len(got))
+	}
+	if n := len([]rune(got[0].text)); n > maxParagraphRunes || !strings.HasSuffix(got[0].text, ".") {
+		t.Errorf("paragraph of %d runes ending %q, want whole sentences within %d", n, got[0].text[len(got[0].text)-5:], maxParagraphRunes)
+	}
+}
+
+func TestNoteTags(t *testing.T) {
+	src := "---\ntags:\n  - Work\n  - \"projects/go\"\n---\nText with #idea and #2024 and a [link](#anchor).\n```\n#not-a-tag\n```\n"
+	want := []string{"work", "projects/go", "idea"}
+	if got := noteTags(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("noteTags() = %q, want %q", got, want)
+	}
+
+	if !hasTag(want, "#Projects") {
+		t.Error("expected a nested tag to match its parent")
+	}
+	if hasTag(want, "proj") {
+		t.Error("expected a tag prefix not to match")
+	}
+}
@@ -1,18 +1,23 @@
 package plugins
 
+import "context"
+
 // Content represents the data returned by a plugin
 type Content struct {
-	Text      string
-	SourceURL string // Optional URL
-	Author    string // Optional
+	Text      string `json:"text"`
+	SourceURL string `json:"source_url,omitempty"` // Optional URL
+	Author    string `json:"author,omitempty"`     // Optional
+	Code      bool   `json:"code,omitempty"`       // Source code, typed with its line breaks and indentation
+	Stale     bool   `json:"-"`                    // Served from the offline cache after a failed fetch
 }
 
 // ContentSource defines the interface for data sources that provide text to type.
 type ContentSource interface {
 	// Name returns the display name of the plugin
 	Name() string
-	// GetContent returns text for the user to type and optional metadata
-	GetContent() (*Content, error)
+	// GetContent returns text for the user to type and optional metadata.
+	// It should give up and return ctx.Err() once ctx is done.
+	GetContent(ctx context.Context) (*Content, error)
 	// Description returns a brief description of what the plugin provides
 	Description() string
 }
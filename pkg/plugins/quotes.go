@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+//go:embed data/quotes.json
+var quotesJSON []byte
+
+type quote struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+}
+
+var builtinQuotes = sync.OnceValues(func() ([]quote, error) {
+	var quotes []quote
+	if err := json.Unmarshal(quotesJSON, &quotes); err != nil {
+		return nil, fmt.Errorf("failed to parse built-in quotes: %w", err)
+	}
+	return quotes, nil
+})
+
+func init() {
+	Register(Registration{
+		Name:        "quotes",
+		Description: "Famous quotes and opening lines",
+		Category:    "quotes",
+		Language:    "en",
+		Online:      false,
+		Factory:     func() ContentSource { return &QuotesSource{} },
+	})
+}
+
+// QuotesSource picks from a curated set of quotes and opening lines of
+// books built into the binary
+type QuotesSource struct {
+	mu   sync.Mutex
+	last int // Index of the previous quote, plus one, so it isn't repeated
+}
+
+func (q *QuotesSource) Name() string {
+	return "Quotes"
+}
+
+func (q *QuotesSource) Description() string {
+	return "Types out famous quotes and opening lines of classic books"
+}
+
+func (q *QuotesSource) GetContent(ctx context.Context) (*Content, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	quotes, err := builtinQuotes()
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := rand.Intn(len(quotes))
+	if i+1 == q.last && len(quotes) > 1 {
+		i = (i + 1) % len(quotes)
+	}
+	q.last = i + 1
+	return &Content{Text: quotes[i].Text, Author: quotes[i].Author}, nil
+}
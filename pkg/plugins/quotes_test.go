@@ -0,0 +1,49 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinQuotes(t *testing.T) {
+	quotes, err := builtinQuotes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) < 100 {
+		t.Errorf("got %d quotes, want a corpus of at least 100", len(quotes))
+	}
+
+	seen := make(map[string]bool)
+	for _, q := range quotes {
+		if strings.TrimSpace(q.Text) != q.Text || strings.Contains(q.Text, "  ") || q.Text == "" {
+			t.Errorf("quote %q has stray whitespace", q.Text)
+		}
+		if q.Author == "" {
+			t.Errorf("quote %q has no author", q.Text)
+		}
+		if seen[q.Text] {
+			t.Errorf("quote %q is listed twice", q.Text)
+		}
+		seen[q.Text] = true
+	}
+}
+
+func TestQuotesSource_GetContent(t *testing.T) {
+	q := &QuotesSource{}
+	previous := ""
+	for i := 0; i < 20; i++ {
+		content, err := q.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Text == "" || content.Author == "" {
+			t.Fatalf("got %+v, want a quote and its author", content)
+		}
+		if content.Text == previous {
+			t.Errorf("quote %q repeated back to back", content.Text)
+		}
+		previous = content.Text
+	}
+}
@@ -0,0 +1,122 @@
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SettingType describes the kind of value a plugin setting holds
+type SettingType string
+
+const (
+	SettingString SettingType = "string"
+	SettingInt    SettingType = "int"
+	SettingBool   SettingType = "bool"
+	SettingEnum   SettingType = "enum" // One of Options
+	SettingList   SettingType = "list" // Comma-separated strings
+)
+
+// Setting declares one option a plugin accepts. Values are always passed
+// around as strings so they can be stored in the config and given on the CLI.
+type Setting struct {
+	Key         string      `json:"key"`
+	Label       string      `json:"label"`
+	Description string      `json:"description,omitempty"`
+	Type        SettingType `json:"type"`
+	Default     string      `json:"default,omitempty"`
+	Options     []string    `json:"options,omitempty"` // Allowed values of an enum
+}
+
+// Configurable is implemented by plugins that take options
+type Configurable interface {
+	// Settings lists the options the plugin understands
+	Settings() []Setting
+	// Configure applies values for every setting, keyed by Setting.Key
+	Configure(values map[string]string) error
+}
+
+// Validate checks that value is acceptable for the setting
+func (s Setting) Validate(value string) error {
+	switch s.Type {
+	case SettingInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be a whole number", s.Key)
+		}
+	case SettingBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", s.Key)
+		}
+	case SettingEnum:
+		for _, option := range s.Options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s", s.Key, strings.Join(s.Options, ", "))
+	}
+	return nil
+}
+
+// FindSetting looks up a setting by key
+func FindSetting(c Configurable, key string) (Setting, bool) {
+	for _, s := range c.Settings() {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return Setting{}, false
+}
+
+// Values fills in defaults for every setting missing from stored
+func Values(c Configurable, stored map[string]string) map[string]string {
+	values := make(map[string]string)
+	for _, s := range c.Settings() {
+		values[s.Key] = s.Default
+		if v, ok := stored[s.Key]; ok {
+			values[s.Key] = v
+		}
+	}
+	return values
+}
+
+// Configure applies stored options to src if it is configurable. Invalid or
+// unknown stored values fall back to the setting's default.
+func Configure(src ContentSource, stored map[string]string) error {
+	c, ok := src.(Configurable)
+	if !ok {
+		return nil
+	}
+	values := Values(c, stored)
+	for _, s := range c.Settings() {
+		if s.Validate(values[s.Key]) != nil {
+			values[s.Key] = s.Default
+		}
+	}
+	return c.Configure(values)
+}
+
+// ParseList splits a list setting into its trimmed, non-empty items
+func ParseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// expandHome replaces a leading ~ in a path setting with the home directory
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && rest[0] != '/') {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", path, err)
+	}
+	return home + rest, nil
+}
@@ -0,0 +1,79 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSettingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting Setting
+		value   string
+		wantErr bool
+	}{
+		{"Int", Setting{Key: "n", Type: SettingInt}, "42", false},
+		{"IntInvalid", Setting{Key: "n", Type: SettingInt}, "many", true},
+		{"Bool", Setting{Key: "b", Type: SettingBool}, "true", false},
+		{"BoolInvalid", Setting{Key: "b", Type: SettingBool}, "yes please", true},
+		{"Enum", Setting{Key: "e", Type: SettingEnum, Options: []string{"top", "new"}}, "new", false},
+		{"EnumInvalid", Setting{Key: "e", Type: SettingEnum, Options: []string{"top", "new"}}, "old", true},
+		{"String", Setting{Key: "s", Type: SettingString}, "anything", false},
+		{"List", Setting{Key: "l", Type: SettingList}, "a, b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.setting.Validate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseList(t *testing.T) {
+	got := ParseList(" golang/go, ,charmbracelet/bubbletea ")
+	want := []string{"golang/go", "charmbracelet/bubbletea"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseList() = %q, want %q", got, want)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	h := NewHackerNewsSource(HTTPOptions{})
+
+	if err := Configure(h, map[string]string{"stories": "10"}); err != nil {
+		t.Fatal(err)
+	}
+	if h.Stories != 10 {
+		t.Errorf("Stories = %d, want 10", h.Stories)
+	}
+
+	// Invalid stored values fall back to the default
+	if err := Configure(h, map[string]string{"stories": "lots"}); err != nil {
+		t.Fatal(err)
+	}
+	if h.Stories != defaultHNStories {
+		t.Errorf("Stories = %d, want default %d", h.Stories, defaultHNStories)
+	}
+}
+
+func TestGitHubConfigure(t *testing.T) {
+	g := NewGitHubSource(HTTPOptions{})
+
+	err := Configure(g, map[string]string{"repos": "golang/go,golang/tools", "paths": "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"golang/go", "golang/tools"}; !reflect.DeepEqual(g.Repos, want) {
+		t.Errorf("Repos = %q, want %q", g.Repos, want)
+	}
+	if g.Branch != defaultGitHubBranch {
+		t.Errorf("Branch = %q, want default %q", g.Branch, defaultGitHubBranch)
+	}
+
+	if err := Configure(g, map[string]string{"repos": "not-a-repo"}); err == nil {
+		t.Error("expected an error for a repository without an owner")
+	}
+}
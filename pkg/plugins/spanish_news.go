@@ -1,30 +1,43 @@
 package plugins
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"math/rand"
-	"net/http"
-	"time"
+	"strings"
 )
 
-type SpanishNewsSource struct{}
+const (
+	elPaisBaseURL  = "https://elpais.com"
+	elPaisFeedPath = "/rss/elpais/portada.xml"
+)
 
-type RSS struct {
-	Channel Channel `xml:"channel"`
+func init() {
+	Register(Registration{
+		Name:        "spanish-news",
+		Aliases:     []string{"elpais"},
+		Description: "Headlines from El País",
+		Category:    "news",
+		Language:    "es",
+		Online:      true,
+		Factory:     func() ContentSource { return NewSpanishNewsSource(HTTPOptions{}) },
+	})
 }
 
-type Channel struct {
-	Items []Item `xml:"item"`
+type SpanishNewsSource struct {
+	FeedURL string
+	http    HTTPOptions
 }
 
-type Item struct {
-	Title string `xml:"title"`
-	Link  string `xml:"link"`
+func NewSpanishNewsSource(opts HTTPOptions) *SpanishNewsSource {
+	s := &SpanishNewsSource{http: opts.withDefaults(elPaisBaseURL)}
+	s.FeedURL = s.defaultFeed()
+	return s
 }
 
-func NewSpanishNewsSource() *SpanishNewsSource {
-	return &SpanishNewsSource{}
+// defaultFeed is the El País front page feed on the configured base URL
+func (s *SpanishNewsSource) defaultFeed() string {
+	return s.http.BaseURL + elPaisFeedPath
 }
 
 func (s *SpanishNewsSource) Name() string {
@@ -35,32 +48,52 @@ func (s *SpanishNewsSource) Description() string {
 	return "Headlines from El País (Spanish)"
 }
 
-func (s *SpanishNewsSource) GetContent() (*Content, error) {
-	resp, err := http.Get("https://elpais.com/rss/elpais/portada.xml")
+func (s *SpanishNewsSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "feed",
+			Label:       "Feed URL",
+			Description: "RSS, Atom or RDF feed the headlines are taken from",
+			Type:        SettingString,
+			Default:     s.defaultFeed(),
+		},
+	}
+}
+
+func (s *SpanishNewsSource) Configure(values map[string]string) error {
+	feed := strings.TrimSpace(values["feed"])
+	if feed == "" {
+		return fmt.Errorf("feed URL is empty")
+	}
+	s.FeedURL = feed
+	return nil
+}
+
+func (s *SpanishNewsSource) GetContent(ctx context.Context) (*Content, error) {
+	body, err := s.http.fetch(ctx, s.FeedURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch feed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	var rss RSS
-	if err := xml.NewDecoder(resp.Body).Decode(&rss); err != nil {
-		return nil, fmt.Errorf("failed to decode feed: %w", err)
+	parsed, err := parseFeed(body)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(rss.Channel.Items) == 0 {
+	if len(parsed.Items) == 0 {
 		return nil, fmt.Errorf("no stories found")
 	}
 
-	rand.Seed(time.Now().UnixNano())
-	item := rss.Channel.Items[rand.Intn(len(rss.Channel.Items))]
+	item := parsed.Items[rand.Intn(len(parsed.Items))]
 
-	// Clean up title (sometimes it has CDATA or other artifacts, but XML decoder handles CDATA)
-	// We might want to remove " - EL PAÍS" suffix if it exists, or similar cleanup.
-	// For now, raw title is probably fine.
+	author := "El País"
+	if s.FeedURL != s.defaultFeed() && parsed.Title != "" {
+		author = feedText(parsed.Title)
+	}
 
 	return &Content{
-		Text:      item.Title,
+		Text:      cleanTitle(item.Title, parsed.Title, s.FeedURL),
 		SourceURL: item.Link,
-		Author:    "El País",
+		Author:    author,
 	}, nil
 }
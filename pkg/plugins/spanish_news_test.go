@@ -1,17 +1,18 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
-func TestSpanishNewsSource_GetContent(t *testing.T) {
-	// Mock RSS feed
-	mockRSS := `<?xml version="1.0" encoding="UTF-8"?>
+const mockRSS = `<?xml version="1.0" encoding="UTF-8"?>
 <rss version="2.0">
 <channel>
+ <title>Portada</title>
  <item>
   <title>Noticia de prueba</title>
   <link>https://example.com/noticia</link>
@@ -19,26 +20,121 @@ func TestSpanishNewsSource_GetContent(t *testing.T) {
 </channel>
 </rss>`
 
+func TestSpanishNewsSource_Metadata(t *testing.T) {
+	plugin := NewSpanishNewsSource(HTTPOptions{})
+
+	if plugin.Name() != "Spanish News" {
+		t.Errorf("expected name 'Spanish News', got '%s'", plugin.Name())
+	}
+
+	if plugin.Description() == "" {
+		t.Error("expected description to be non-empty")
+	}
+}
+
+func TestSpanishNewsSource_GetContent(t *testing.T) {
+	var userAgent string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != elPaisFeedPath {
+			http.NotFound(w, r)
+			return
+		}
+		userAgent = r.UserAgent()
 		fmt.Fprintln(w, mockRSS)
 	}))
 	defer ts.Close()
 
-	// We can't easily inject the URL into SpanishNewsSource without modifying it to accept a URL or using a global var.
-	// For now, let's just test that the struct conforms to the interface and basic properties.
-	// To properly test GetContent with a mock, we'd need to refactor SpanishNewsSource to allow dependency injection of the URL or HTTP client.
-	// Given the simple nature of the task, I will test the Name and Description, and maybe skip the network call test or refactor if needed.
-	// Actually, I can check if the code allows modifying the URL. It currently hardcodes it.
-	// I'll refactor the code slightly to make it testable or just test the non-network parts for now.
+	plugin := NewSpanishNewsSource(HTTPOptions{BaseURL: ts.URL, UserAgent: "test-agent"})
+	content, err := plugin.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	// Let's just test Name and Description for now to ensure basic compliance.
-	plugin := NewSpanishNewsSource()
+	if content.Text != "Noticia de prueba" {
+		t.Errorf("Text = %q, want %q", content.Text, "Noticia de prueba")
+	}
+	if content.SourceURL != "https://example.com/noticia" {
+		t.Errorf("SourceURL = %q, want %q", content.SourceURL, "https://example.com/noticia")
+	}
+	if content.Author != "El País" {
+		t.Errorf("Author = %q, want %q", content.Author, "El País")
+	}
+	if userAgent != "test-agent" {
+		t.Errorf("User-Agent = %q, want %q", userAgent, "test-agent")
+	}
+}
 
-	if plugin.Name() != "Spanish News" {
-		t.Errorf("expected name 'Spanish News', got '%s'", plugin.Name())
+func TestSpanishNewsSource_CustomFeed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, mockRSS)
+	}))
+	defer ts.Close()
+
+	plugin := NewSpanishNewsSource(HTTPOptions{})
+	if err := Configure(plugin, map[string]string{"feed": ts.URL + "/other.xml"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := plugin.GetContent(context.Background())
+	if err != nil {
+		t.Fatal(err)
 	}
+	if content.Author != "Portada" {
+		t.Errorf("Author = %q, want the channel title", content.Author)
+	}
+}
 
-	if plugin.Description() == "" {
-		t.Error("expected description to be non-empty")
+func TestSpanishNewsSource_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"Malformed", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "<rss><channel><item>")
+		}},
+		{"Empty", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `<rss version="2.0"><channel></channel></rss>`)
+		}},
+		{"HTTPError", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			plugin := NewSpanishNewsSource(HTTPOptions{BaseURL: ts.URL})
+			if _, err := plugin.GetContent(context.Background()); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSpanishNewsSource_Timeout(t *testing.T) {
+	ts := slowServer(t, mockRSS)
+
+	plugin := NewSpanishNewsSource(HTTPOptions{BaseURL: ts.URL, Timeout: 20 * time.Millisecond})
+	if _, err := plugin.GetContent(context.Background()); err == nil {
+		t.Error("expected a timeout error")
 	}
 }
+
+// slowServer responds with body only after the client has given up
+func slowServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(func() {
+		close(done)
+		ts.Close()
+	})
+	return ts
+}
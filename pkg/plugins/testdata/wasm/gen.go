@@ -0,0 +1,206 @@
+//go:build ignore
+
+// gen writes summary.wasm, the WebAssembly plugin TestWasmSource loads. It
+// is assembled by hand so the test needs no WebAssembly toolchain; run
+// `go run gen.go` in this directory after changing it.
+//
+// The module speaks the plugin protocol at its smallest:
+//
+//	(import "go_racer" "fetch" (func $fetch (param i32 i32) (result i32)))
+//	(import "go_racer" "take" (func $take (param i32)))
+//	(memory (export "memory") 1)
+//	(data (i32.const 0) describeJSON)
+//	(data (i32.const urlAt) summaryURL)
+//	(data (i32.const errorAt) "{\"error\":\"")
+//
+//	;; Every request is copied to the same place
+//	(func (export "alloc") (param i32) (result i32) (i32.const requestAt))
+//
+//	(func (export "handle") (param $ptr i32) (param $len i32) (result i64)
+//	  (local $n i32)
+//	  ;; {"method":"describe"...} has a d where get_content has a g
+//	  (if (result i64) (i32.eq (i32.load8_u offset=11 (local.get $ptr)) (i32.const 'd'))
+//	    (then (i64.const len(describeJSON)))
+//	    (else
+//	      (local.set $n (call $fetch (i32.const urlAt) (i32.const len(summaryURL))))
+//	      (if (result i64) (i32.lt_s (local.get $n) (i32.const 0))
+//	        ;; {"error":"<message>"}
+//	        (then
+//	          (memory.copy (i32.const answerAt) (i32.const errorAt) (i32.const len(errorPrefix)))
+//	          (call $take (i32.const answerAt+len(errorPrefix)))
+//	          (i32.store16 (i32.add (i32.const answerAt+len(errorPrefix)) (i32.sub (i32.const 0) (local.get $n))) (i32.const '"}'))
+//	          (i64.or (i64.const answerAt<<32)
+//	            (i64.extend_i32_u (i32.add (i32.const len(errorPrefix)+2) (i32.sub (i32.const 0) (local.get $n))))))
+//	        ;; The fetched body is the answer
+//	        (else
+//	          (call $take (i32.const answerAt))
+//	          (i64.or (i64.const answerAt<<32) (i64.extend_i32_u (local.get $n))))))))
+package main
+
+import (
+	"log"
+	"os"
+)
+
+const (
+	describeJSON = `{"name":"Summary","description":"Types a random Wikipedia summary","hosts":["*.wikipedia.org"],"settings":[{"key":"lang","label":"Language","type":"string","default":"en"}]}`
+	summaryURL   = "https://en.wikipedia.org/api/rest_v1/page/random/summary"
+	errorPrefix  = `{"error":"`
+
+	urlAt     = 1024
+	errorAt   = 2048
+	requestAt = 4096
+	answerAt  = 8192
+
+	describeN = len(describeJSON)
+	urlN      = len(summaryURL)
+	errorN    = len(errorPrefix)
+	messageAt = answerAt + errorN
+)
+
+func main() {
+	var b []byte
+	b = append(b, 0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00)
+
+	const i32, i64 = 0x7f, 0x7e
+	b = section(b, 1, vec(
+		funcType([]byte{i32, i32}, []byte{i32}), // fetch
+		funcType([]byte{i32}, nil),              // take
+		funcType([]byte{i32}, []byte{i32}),      // alloc
+		funcType([]byte{i32, i32}, []byte{i64}), // handle
+	))
+	b = section(b, 2, vec(
+		cat(name("go_racer"), name("fetch"), []byte{0x00, 0}),
+		cat(name("go_racer"), name("take"), []byte{0x00, 1}),
+	))
+	b = section(b, 3, vec([]byte{2}, []byte{3}))
+	b = section(b, 5, vec([]byte{0x00, 1}))
+	b = section(b, 7, vec(
+		cat(name("memory"), []byte{0x02, 0}),
+		cat(name("alloc"), []byte{0x00, 2}),
+		cat(name("handle"), []byte{0x00, 3}),
+	))
+
+	alloc := cat([]byte{0}, i32Const(requestAt), []byte{0x0b})
+	// Each line is one instruction
+	handle := cat(
+		[]byte{1, 1, i32},          // (local $n i32)
+		[]byte{0x20, 0},            // local.get $ptr
+		[]byte{0x2d, 0, 11},        // i32.load8_u offset=11
+		i32Const('d'),              // i32.const 'd'
+		[]byte{0x46},               // i32.eq
+		[]byte{0x04, i64},          // if (result i64)
+		i64Const(int64(describeN)), //   i64.const len(describeJSON)
+		[]byte{0x05},               // else
+		i32Const(urlAt),            //   i32.const urlAt
+		i32Const(urlN),             //   i32.const len(summaryURL)
+		[]byte{0x10, 0},            //   call $fetch
+		[]byte{0x22, 2},            //   local.tee $n
+		i32Const(0),                //   i32.const 0
+		[]byte{0x48},               //   i32.lt_s
+		[]byte{0x04, i64},          //   if (result i64)
+		i32Const(answerAt),         //     i32.const answerAt
+		i32Const(errorAt),          //     i32.const errorAt
+		i32Const(errorN),           //     i32.const len(errorPrefix)
+		[]byte{0xfc, 10, 0, 0},     //     memory.copy
+		i32Const(messageAt),        //     i32.const answerAt+len(errorPrefix)
+		[]byte{0x10, 1},            //     call $take
+		i32Const(messageAt),        //     i32.const answerAt+len(errorPrefix)
+		i32Const(0),                //     i32.const 0
+		[]byte{0x20, 2},            //     local.get $n
+		[]byte{0x6b},               //     i32.sub
+		[]byte{0x6a},               //     i32.add
+		i32Const('"'|'}'<<8),       //     i32.const '"}'
+		[]byte{0x3b, 0, 0},         //     i32.store16
+		i64Const(answerAt<<32),     //     i64.const answerAt<<32
+		i32Const(errorN+2),         //     i32.const len(errorPrefix)+2
+		i32Const(0),                //     i32.const 0
+		[]byte{0x20, 2},            //     local.get $n
+		[]byte{0x6b},               //     i32.sub
+		[]byte{0x6a},               //     i32.add
+		[]byte{0xad},               //     i64.extend_i32_u
+		[]byte{0x84},               //     i64.or
+		[]byte{0x05},               //   else
+		i32Const(answerAt),         //     i32.const answerAt
+		[]byte{0x10, 1},            //     call $take
+		i64Const(answerAt<<32),     //     i64.const answerAt<<32
+		[]byte{0x20, 2},            //     local.get $n
+		[]byte{0xad},               //     i64.extend_i32_u
+		[]byte{0x84},               //     i64.or
+		[]byte{0x0b},               //   end
+		[]byte{0x0b},               // end
+		[]byte{0x0b},               // end of the function
+	)
+	b = section(b, 10, vec(name(string(alloc)), name(string(handle))))
+
+	b = section(b, 11, vec(
+		data(0, describeJSON),
+		data(urlAt, summaryURL),
+		data(errorAt, errorPrefix),
+	))
+
+	if err := os.WriteFile("summary.wasm", b, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func cat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func section(b []byte, id byte, content []byte) []byte {
+	return append(append(append(b, id), uleb(uint64(len(content)))...), content...)
+}
+
+func vec(items ...[]byte) []byte {
+	return append(uleb(uint64(len(items))), cat(items...)...)
+}
+
+// name is a length-prefixed string, or the body of a function
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func funcType(params, results []byte) []byte {
+	return cat([]byte{0x60}, name(string(params)), name(string(results)))
+}
+
+func data(offset int, s string) []byte {
+	return cat([]byte{0x00}, i32Const(offset), []byte{0x0b}, name(s))
+}
+
+func i32Const(v int) []byte {
+	return append([]byte{0x41}, sleb(int64(v))...)
+}
+
+func i64Const(v int64) []byte {
+	return append([]byte{0x42}, sleb(v)...)
+}
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v == 0 {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func sleb(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
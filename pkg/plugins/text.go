@@ -0,0 +1,116 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// textPassage is one paragraph, or part of a long one, to type
+type textPassage struct {
+	Text string
+	Line int // Where the paragraph starts, from 1
+}
+
+// TextSource types text given on the command line, read from a file or piped
+// in, one paragraph at a time in order and starting over after the last. It
+// isn't registered, as there is nothing to fetch once the text is read.
+type TextSource struct {
+	mu       sync.Mutex
+	name     string
+	path     string // File the text was read from, if any, for linking back
+	passages []textPassage
+	next     int
+}
+
+// NewTextSource splits text into paragraphs at blank lines, breaking long
+// ones between sentences. Pass the path of the file it came from, if any.
+func NewTextSource(name, path, text string) (*TextSource, error) {
+	passages := textPassages(text)
+	if len(passages) == 0 {
+		return nil, errors.New("no text to type")
+	}
+	return &TextSource{name: name, path: path, passages: passages}, nil
+}
+
+func (t *TextSource) Name() string {
+	return t.name
+}
+
+func (t *TextSource) Description() string {
+	return fmt.Sprintf("Types your own text, %d passages in order", len(t.passages))
+}
+
+func (t *TextSource) GetContent(ctx context.Context) (*Content, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	passage := t.passages[t.next]
+	t.next = (t.next + 1) % len(t.passages)
+	t.mu.Unlock()
+
+	content := &Content{Text: passage.Text}
+	if t.path != "" {
+		content.SourceURL = fileURL(t.path, passage.Line)
+	}
+	return content, nil
+}
+
+// textPassages collapses the whitespace within each paragraph of text
+func textPassages(text string) []textPassage {
+	var passages []textPassage
+	var current []string
+	start := 0
+	flush := func() {
+		for _, chunk := range textChunks(strings.Join(current, " ")) {
+			passages = append(passages, textPassage{Text: chunk, Line: start})
+		}
+		current = nil
+	}
+
+	for i, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			flush()
+			continue
+		}
+		if len(current) == 0 {
+			start = i + 1
+		}
+		current = append(current, strings.Join(fields, " "))
+	}
+	flush()
+	return passages
+}
+
+// textChunks breaks a paragraph longer than maxParagraphRunes into runs of
+// whole sentences, so none of the text is lost
+func textChunks(p string) []string {
+	if p == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(p) <= maxParagraphRunes {
+		return []string{p}
+	}
+
+	var chunks []string
+	current := ""
+	for _, sentence := range splitSentences(p) {
+		if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(sentence) > maxParagraphRunes {
+			chunks = append(chunks, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += sentence
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
@@ -0,0 +1,62 @@
+package plugins
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTextSource_GetContent(t *testing.T) {
+	text := "First  paragraph,\nover two lines.\r\n\r\n\n   \nSecond one.\n"
+	src, err := NewTextSource("notes.txt", "/home/me/notes.txt", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Content{
+		{Text: "First paragraph, over two lines.", SourceURL: "file:///home/me/notes.txt#L1"},
+		{Text: "Second one.", SourceURL: "file:///home/me/notes.txt#L6"},
+		{Text: "First paragraph, over two lines.", SourceURL: "file:///home/me/notes.txt#L1"},
+	}
+	for i, w := range want {
+		content, err := src.GetContent(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *content != w {
+			t.Errorf("content %d = %+v, want %+v", i, *content, w)
+		}
+	}
+}
+
+func TestTextSource_Empty(t *testing.T) {
+	for _, text := range []string{"", " \n\t\n"} {
+		if _, err := NewTextSource("stdin", "", text); err == nil {
+			t.Errorf("NewTextSource(%q): expected an error", text)
+		}
+	}
+}
+
+func TestTextPassages_Long(t *testing.T) {
+	sentence := strings.Repeat("word ", 20) + "end."
+	long := strings.TrimSpace(strings.Repeat(sentence+" ", 20))
+
+	passages := textPassages(long)
+	if len(passages) < 2 {
+		t.Fatalf("got %d passages, want the paragraph broken up", len(passages))
+	}
+	var texts []string
+	for _, p := range passages {
+		if n := utf8.RuneCountInString(p.Text); n > maxParagraphRunes {
+			t.Errorf("passage of %d runes, want at most %d", n, maxParagraphRunes)
+		}
+		if !strings.HasSuffix(p.Text, "end.") || p.Line != 1 {
+			t.Errorf("passage %+v, want whole sentences from line 1", p)
+		}
+		texts = append(texts, p.Text)
+	}
+	if got := strings.Join(texts, " "); got != long {
+		t.Error("passages don't add up to the whole paragraph")
+	}
+}
@@ -0,0 +1,365 @@
+package plugins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	wasmHostModule  = "go_racer" // Module the host functions are imported from
+	wasmMemoryPages = 1024       // 64 KiB pages, so 64 MiB of memory at most
+	allowHostsKey   = "allow_hosts"
+)
+
+var (
+	wasmCacheMu  sync.Mutex
+	wasmCacheDir string
+	wasmShared   wazero.CompilationCache
+)
+
+// SetWasmCacheDir keeps compiled WebAssembly plugins in dir, so they are
+// only compiled again when they change. Call it before LoadExternal.
+func SetWasmCacheDir(dir string) {
+	wasmCacheMu.Lock()
+	defer wasmCacheMu.Unlock()
+	wasmCacheDir, wasmShared = dir, nil
+}
+
+// wasmCache shares compiled modules between every WebAssembly plugin, on
+// disk if there is a cache directory and in memory otherwise
+func wasmCache() wazero.CompilationCache {
+	wasmCacheMu.Lock()
+	defer wasmCacheMu.Unlock()
+	if wasmShared == nil && wasmCacheDir != "" {
+		if cache, err := wazero.NewCompilationCacheWithDir(wasmCacheDir); err == nil {
+			wasmShared = cache
+		}
+	}
+	if wasmShared == nil {
+		wasmShared = wazero.NewCompilationCache()
+	}
+	return wasmShared
+}
+
+// WasmSource is a plugin compiled to WebAssembly and run in a sandbox. It
+// speaks the same JSON as an ExternalSource, through its exported alloc and
+// handle functions, and has no filesystem or environment. The only way out
+// is the fetch host function, which is limited to the hosts the user allows.
+type WasmSource struct {
+	Path string
+	describedPlugin
+
+	hosts  []string // Hosts the plugin asked for, only ever suggested
+	allow  []string // Hosts the user allows, none until configured
+	values map[string]string
+
+	mu       sync.Mutex
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	mod      api.Module
+	pending  []byte // Result of the last fetch, waiting to be taken
+	stderr   tailBuffer
+	http     HTTPOptions
+}
+
+// NewWasmSource returns a plugin that runs the module at path. It may not
+// fetch anything until the user allows hosts, and the hosts it asks for are
+// only suggested.
+func NewWasmSource(path string, plugin describedPlugin, hosts []string) *WasmSource {
+	return &WasmSource{
+		Path:            path,
+		describedPlugin: plugin,
+		hosts:           hosts,
+		http:            HTTPOptions{}.withDefaults(""),
+	}
+}
+
+// Settings returns the plugin's own settings and the hosts it may fetch from
+func (s *WasmSource) Settings() []Setting {
+	desc := "Hosts the plugin may fetch from; *.example.com allows subdomains"
+	if len(s.hosts) > 0 {
+		desc += ". It asks for " + strings.Join(s.hosts, ", ")
+	}
+	return append(append([]Setting{}, s.settings...), Setting{
+		Key:         allowHostsKey,
+		Label:       "Allowed hosts",
+		Description: desc,
+		Type:        SettingList,
+	})
+}
+
+func (s *WasmSource) Configure(values map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]string, len(values))
+	for k, v := range values {
+		if k != allowHostsKey {
+			s.values[k] = v
+		}
+	}
+	s.allow = ParseList(values[allowHostsKey])
+	return nil
+}
+
+func (s *WasmSource) GetContent(ctx context.Context) (*Content, error) {
+	if err := s.compile(ctx); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, externalContentTimeout)
+	defer cancel()
+
+	s.mu.Lock()
+	values := s.values
+	s.mu.Unlock()
+
+	resp, err := s.call(ctx, ExternalRequest{Method: MethodGetContent, Settings: values})
+	if err != nil {
+		return nil, err
+	}
+	return externalContent(s.name, resp)
+}
+
+// Stderr returns the last output the plugin wrote to stdout or stderr
+func (s *WasmSource) Stderr() string {
+	return s.stderr.String()
+}
+
+// Close frees the plugin's instance and compiled module
+func (s *WasmSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runtime == nil {
+		return nil
+	}
+	err := s.runtime.Close(context.Background())
+	s.runtime, s.compiled, s.mod = nil, nil, nil
+	return err
+}
+
+// stop throws the instance away, keeping the compiled module to start again
+func (s *WasmSource) stop() {
+	if s.mod != nil {
+		s.mod.Close(context.Background())
+		s.mod = nil
+	}
+}
+
+// compile sets up the runtime and compiles the module, unless that is done
+// already. Callers do this before setting a deadline, as compiling a large
+// module can take longer than the plugin has to answer.
+func (s *WasmSource) compile(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.prepare(ctx); err != nil {
+		return s.stderr.annotate(s.name, err)
+	}
+	return nil
+}
+
+func (s *WasmSource) prepare(ctx context.Context) error {
+	if s.compiled != nil {
+		return nil
+	}
+	code, err := os.ReadFile(s.Path)
+	if err != nil {
+		return err
+	}
+
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(wasmCache()).
+		WithMemoryLimitPages(wasmMemoryPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		r.Close(ctx)
+		return err
+	}
+	_, err = r.NewHostModuleBuilder(wasmHostModule).
+		NewFunctionBuilder().WithFunc(s.hostFetch).Export("fetch").
+		NewFunctionBuilder().WithFunc(s.hostTake).Export("take").
+		Instantiate(ctx)
+	if err != nil {
+		r.Close(ctx)
+		return err
+	}
+
+	compiled, err := r.CompileModule(ctx, code)
+	if err != nil {
+		r.Close(ctx)
+		return fmt.Errorf("failed to compile: %w", err)
+	}
+	s.runtime, s.compiled = r, compiled
+	return nil
+}
+
+// call sends one request, instantiating the module if needed. An instance
+// that traps or runs out of time is thrown away and started afresh next time.
+func (s *WasmSource) call(ctx context.Context, req ExternalRequest) (*ExternalResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mod == nil {
+		err := s.prepare(ctx)
+		if err == nil {
+			err = s.start(ctx)
+		}
+		if err != nil {
+			s.stop()
+			return nil, s.stderr.annotate(s.name, err)
+		}
+	}
+
+	resp, err := s.handle(ctx, req)
+	if err != nil {
+		s.stop()
+		if ctx.Err() != nil {
+			err = fmt.Errorf("no answer to %s: %w", req.Method, ctx.Err())
+		}
+		return nil, s.stderr.annotate(s.name, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", s.name, resp.Error)
+	}
+	return resp, nil
+}
+
+// start instantiates the compiled module
+func (s *WasmSource) start(ctx context.Context) error {
+	// No filesystem, environment or arguments are passed in. Instances are
+	// left unnamed so a fresh one can replace one that was thrown away.
+	var err error
+	s.mod, err = s.runtime.InstantiateModule(ctx, s.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStdout(&s.stderr).
+		WithStderr(&s.stderr).
+		WithRandSource(rand.Reader).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return fmt.Errorf("failed to start: %w", err)
+	}
+	for _, fn := range []string{"alloc", "handle"} {
+		if s.mod.ExportedFunction(fn) == nil {
+			return fmt.Errorf("module does not export %s", fn)
+		}
+	}
+	return nil
+}
+
+// handle copies req into the module's memory and reads back its answer
+func (s *WasmSource) handle(ctx context.Context, req ExternalRequest) (*ExternalResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.mod.ExportedFunction("alloc").Call(ctx, uint64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", req.Method, err)
+	}
+	ptr := uint32(res[0])
+	if !s.mod.Memory().Write(ptr, data) {
+		return nil, fmt.Errorf("failed to send %s: alloc returned memory out of range", req.Method)
+	}
+
+	res, err = s.mod.ExportedFunction("handle").Call(ctx, uint64(ptr), uint64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer %s: %w", req.Method, err)
+	}
+	answer, ok := s.mod.Memory().Read(uint32(res[0]>>32), uint32(res[0]))
+	if !ok {
+		return nil, fmt.Errorf("invalid answer to %s: out of range", req.Method)
+	}
+	var resp ExternalResponse
+	if err := json.Unmarshal(answer, &resp); err != nil {
+		return nil, fmt.Errorf("invalid answer to %s: %w", req.Method, err)
+	}
+	return &resp, nil
+}
+
+// hostFetch GETs a URL for the module if its host is allowed. It returns
+// the length of the body, or minus the length of an error message, and
+// keeps either for take.
+func (s *WasmSource) hostFetch(ctx context.Context, m api.Module, urlPtr, urlLen uint32) int32 {
+	s.pending = nil
+	raw, ok := m.Memory().Read(urlPtr, urlLen)
+	if !ok {
+		return s.fetchFailed(errors.New("URL out of range"))
+	}
+	body, err := s.fetch(ctx, string(raw))
+	if err != nil {
+		return s.fetchFailed(err)
+	}
+	s.pending = body
+	return int32(len(body))
+}
+
+func (s *WasmSource) fetchFailed(err error) int32 {
+	s.pending = []byte(err.Error())
+	return -int32(len(s.pending))
+}
+
+// hostTake copies the result of the last fetch to the module's memory
+func (s *WasmSource) hostTake(_ context.Context, m api.Module, bufPtr uint32) {
+	m.Memory().Write(bufPtr, s.pending)
+	s.pending = nil
+}
+
+// fetch GETs rawURL, following redirects only to allowed hosts
+func (s *WasmSource) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.allowed(u); err != nil {
+		return nil, err
+	}
+
+	opts := s.http
+	client := *opts.Client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return s.allowed(req.URL)
+	}
+	opts.Client = &client
+	return opts.fetch(ctx, u.String())
+}
+
+// allowed checks u against the allowed hosts. An entry matches the host
+// exactly, or with a port if it has one, and *.example.com matches any
+// subdomain of example.com.
+func (s *WasmSource) allowed(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s: only http and https are allowed", u.Redacted())
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, entry := range s.allow {
+		entry = strings.ToLower(entry)
+		switch {
+		case strings.HasPrefix(entry, "*."):
+			if strings.HasSuffix(host, entry[1:]) {
+				return nil
+			}
+		case strings.Contains(entry, ":"):
+			if strings.ToLower(u.Host) == entry {
+				return nil
+			}
+		case host == entry:
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not an allowed host; add it to %s to let the plugin fetch from it", u.Host, allowHostsKey)
+}
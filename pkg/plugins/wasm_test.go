@@ -0,0 +1,162 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// copyWasmFixture copies testdata/wasm/summary.wasm, written by gen.go in
+// the same directory, into dir
+func copyWasmFixture(t *testing.T, dir string) {
+	t.Helper()
+	code, err := os.ReadFile(filepath.Join("testdata", "wasm", "summary.wasm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "summary.wasm"), code, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// slowMachine gives plugins plenty of time to answer for the rest of the test
+func slowMachine(t *testing.T) {
+	describe, content := externalDescribeTimeout, externalContentTimeout
+	externalDescribeTimeout, externalContentTimeout = time.Minute, time.Minute
+	t.Cleanup(func() {
+		externalDescribeTimeout, externalContentTimeout = describe, content
+	})
+}
+
+// rewriteTransport sends every request to a test server instead
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWasmSource(t *testing.T) {
+	dir, cacheDir := t.TempDir(), t.TempDir()
+	copyWasmFixture(t, dir)
+	slowMachine(t)
+	SetWasmCacheDir(cacheDir)
+	defer SetWasmCacheDir("")
+	unregister(t, "summary")
+
+	if err := LoadExternal(dir); err != nil {
+		t.Fatal(err)
+	}
+	r, ok := Lookup("summary")
+	if !ok {
+		t.Fatal("summary was not registered")
+	}
+	if !r.Online {
+		t.Error("Online = false, want a plugin that asks for hosts to be online")
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) == 0 {
+		t.Error("the compiled module was not cached on disk")
+	}
+
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.Host+r.URL.Path)
+		w.Write([]byte(`{"content": {"text": "Go is a statically typed, compiled programming language designed at Google.", "source_url": "https://en.wikipedia.org/wiki/Go", "author": "Go"}}`))
+	}))
+	defer ts.Close()
+	target, _ := url.Parse(ts.URL)
+
+	src, err := GetConfiguredPlugin("summary", map[string]string{"lang": "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := src.(*WasmSource)
+	defer s.Close()
+	s.http.Client = &http.Client{Transport: rewriteTransport{target}}
+
+	// The hosts the plugin asks for are only suggested
+	var allow Setting
+	for _, setting := range s.Settings() {
+		if setting.Key == allowHostsKey {
+			allow = setting
+		}
+	}
+	if allow.Default != "" || !strings.Contains(allow.Description, "*.wikipedia.org") {
+		t.Errorf("allow_hosts = %+v, want no default and the requested hosts suggested", allow)
+	}
+
+	// Until the user allows a host, nothing can be fetched
+	if _, err := s.GetContent(context.Background()); err == nil || !strings.Contains(err.Error(), "en.wikipedia.org is not an allowed host") {
+		t.Errorf("err = %v, want the fetch refused", err)
+	}
+	if len(requested) != 0 {
+		t.Errorf("requested = %v, want nothing fetched before hosts are allowed", requested)
+	}
+
+	if err := s.Configure(map[string]string{"lang": "de", allowHostsKey: "*.wikipedia.org"}); err != nil {
+		t.Fatal(err)
+	}
+	content, err := s.GetContent(context.Background())
+	if err != nil {
+		t.Fatalf("GetContent: %v (stderr: %s)", err, s.Stderr())
+	}
+	if !strings.HasPrefix(content.Text, "Go is a statically typed") || content.Author != "Go" || content.SourceURL != "https://en.wikipedia.org/wiki/Go" {
+		t.Errorf("content = %+v, want the article summary", content)
+	}
+	if len(requested) != 1 || !strings.HasSuffix(requested[0], "/api/rest_v1/page/random/summary") {
+		t.Errorf("requested = %v, want one random summary", requested)
+	}
+
+	// Taking the host off the list cuts the plugin off
+	if err := s.Configure(map[string]string{"lang": "de", allowHostsKey: "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetContent(context.Background()); err == nil || !strings.Contains(err.Error(), "en.wikipedia.org is not an allowed host") {
+		t.Errorf("err = %v, want the fetch refused", err)
+	}
+	if len(requested) != 1 {
+		t.Errorf("requested = %v, want no request for a refused host", requested)
+	}
+}
+
+func TestWasmSource_Allowed(t *testing.T) {
+	s := NewWasmSource("plugin.wasm", describedPlugin{name: "plugin"}, []string{"*.wikipedia.org"})
+	if err := s.Configure(map[string]string{allowHostsKey: "*.wikipedia.org, api.example.com, localhost:8080"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://en.wikipedia.org/wiki/Go", true},
+		{"https://EN.Wikipedia.org/wiki/Go", true},
+		{"https://wikipedia.org/", false},
+		{"https://evilwikipedia.org/", false},
+		{"https://api.example.com/v1", true},
+		{"https://api.example.com:8443/v1", true},
+		{"https://www.example.com/", false},
+		{"http://localhost:8080/", true},
+		{"http://localhost:9090/", false},
+		{"file:///etc/passwd", false},
+		{"ftp://api.example.com/", false},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.allowed(u); (err == nil) != tt.want {
+			t.Errorf("allowed(%s) = %v, want allowed %v", tt.url, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package plugins
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultWordList   = "200"
+	defaultWordLength = 30
+	maxWordLength     = 1000
+)
+
+// englishWords holds the 10,000 most common English words, most common first
+//
+//go:embed data/english.txt
+var englishWords string
+
+var rankedWords = sync.OnceValue(func() []string {
+	return strings.Fields(englishWords)
+})
+
+// wordLists maps each list to how many of the top words it picks from
+var wordLists = map[string]int{
+	"200": 200,
+	"1k":  1000,
+	"10k": 10000,
+}
+
+func init() {
+	Register(Registration{
+		Name:        "words",
+		Aliases:     []string{"english"},
+		Description: "Random common English words",
+		Category:    "words",
+		Language:    "en",
+		Online:      false,
+		Factory:     func() ContentSource { return NewWordsSource() },
+	})
+}
+
+// WordsSource strings together random words from a list of the most common
+// English words. It is built into the binary, so it works without a network.
+type WordsSource struct {
+	List   string // "200", "1k" or "10k"
+	Length int    // Words per text
+}
+
+func NewWordsSource() *WordsSource {
+	return &WordsSource{
+		List:   defaultWordList,
+		Length: defaultWordLength,
+	}
+}
+
+func (w *WordsSource) Name() string {
+	return "English Words"
+}
+
+func (w *WordsSource) Description() string {
+	return "Types out random words from the most common English words"
+}
+
+func (w *WordsSource) Settings() []Setting {
+	return []Setting{
+		{
+			Key:         "list",
+			Label:       "Word list",
+			Description: "How many of the most common words to pick from",
+			Type:        SettingEnum,
+			Default:     defaultWordList,
+			Options:     []string{"200", "1k", "10k"},
+		},
+		{
+			Key:         "length",
+			Label:       "Length",
+			Description: "Words in each text",
+			Type:        SettingInt,
+			Default:     strconv.Itoa(defaultWordLength),
+		},
+	}
+}
+
+func (w *WordsSource) Configure(values map[string]string) error {
+	if _, ok := wordLists[values["list"]]; !ok {
+		return fmt.Errorf("unknown word list %q", values["list"])
+	}
+	length, err := strconv.Atoi(values["length"])
+	if err != nil || length < 1 || length > maxWordLength {
+		return fmt.Errorf("length must be between 1 and %d, got %q", maxWordLength, values["length"])
+	}
+	w.List, w.Length = values["list"], length
+	return nil
+}
+
+// GetContent returns Length random words, never the same word twice in a row
+func (w *WordsSource) GetContent(ctx context.Context) (*Content, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	size, ok := wordLists[w.List]
+	if !ok {
+		return nil, fmt.Errorf("unknown word list %q", w.List)
+	}
+	words := rankedWords()
+	size = min(size, len(words))
+
+	picked := make([]string, 0, w.Length)
+	for len(picked) < w.Length {
+		word := words[rand.Intn(size)]
+		if len(picked) > 0 && picked[len(picked)-1] == word {
+			continue
+		}
+		picked = append(picked, word)
+	}
+	return &Content{Text: strings.Join(picked, " ")}, nil
+}
@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRankedWords(t *testing.T) {
+	words := rankedWords()
+	if len(words) != wordLists["10k"] {
+		t.Fatalf("got %d words, want %d", len(words), wordLists["10k"])
+	}
+	if words[0] != "the" {
+		t.Errorf("first word = %q, want the most common word first", words[0])
+	}
+
+	plain := regexp.MustCompile(`^[a-z]+$`)
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if !plain.MatchString(word) {
+			t.Errorf("word %q is not plain lowercase letters", word)
+		}
+		if seen[word] {
+			t.Errorf("word %q is listed twice", word)
+		}
+		seen[word] = true
+	}
+}
+
+func TestWordsSource_GetContent(t *testing.T) {
+	tests := []struct {
+		list   string
+		length string
+	}{
+		{"200", "30"},
+		{"1k", "5"},
+		{"10k", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			w := NewWordsSource()
+			if err := Configure(w, map[string]string{"list": tt.list, "length": tt.length}); err != nil {
+				t.Fatal(err)
+			}
+			content, err := w.GetContent(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			words := strings.Fields(content.Text)
+			if got := len(words); strings.Join(words, " ") != content.Text || got != w.Length {
+				t.Errorf("Text = %q, want %d words separated by single spaces", content.Text, w.Length)
+			}
+			top := rankedWords()[:wordLists[tt.list]]
+			for i, word := range words {
+				if !slices.Contains(top, word) {
+					t.Errorf("word %q is not in the top %s", word, tt.list)
+				}
+				if i > 0 && words[i-1] == word {
+					t.Errorf("word %q repeated back to back", word)
+				}
+			}
+		})
+	}
+}
+
+func TestWordsSource_Configure(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+	}{
+		{"UnknownList", map[string]string{"list": "5k", "length": "10"}},
+		{"ZeroLength", map[string]string{"list": "200", "length": "0"}},
+		{"HugeLength", map[string]string{"list": "200", "length": "100000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewWordsSource().Configure(tt.values); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
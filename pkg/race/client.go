@@ -0,0 +1,80 @@
+package race
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const dialTimeout = 5 * time.Second
+
+// Client is one racer's connection to a race server
+type Client struct {
+	ID       int
+	Messages <-chan Message // Closed when the connection is lost
+
+	conn net.Conn
+	mu   sync.Mutex
+	enc  *json.Encoder
+}
+
+// Dial joins the race server at addr under the given display name
+func Dial(addr, name string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(Message{Type: MsgHello, Name: name}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	dec := json.NewDecoder(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(dialTimeout))
+	var welcome Message
+	if err := dec.Decode(&welcome); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no welcome from race server: %w", err)
+	}
+	if welcome.Type != MsgWelcome {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected %q message from race server", welcome.Type)
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	messages := make(chan Message, 32)
+	go func() {
+		defer close(messages)
+		for {
+			var msg Message
+			if err := dec.Decode(&msg); err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}()
+
+	return &Client{
+		ID:       welcome.ID,
+		Messages: messages,
+		conn:     conn,
+		enc:      enc,
+	}, nil
+}
+
+// SendProgress reports how many runes have been typed, the live WPM and whether the racer finished
+func (c *Client) SendProgress(progress int, wpm float64, done bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return c.enc.Encode(Message{Type: MsgProgress, Progress: progress, WPM: wpm, Done: done})
+}
+
+// Close leaves the race
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,83 @@
+package race
+
+import (
+	"net"
+	"sort"
+)
+
+// DefaultPort is the TCP port used by `go-racer serve` when none is given
+const DefaultPort = 7878
+
+// Message types exchanged between the server and clients. Every message is a
+// single JSON object on its own line.
+const (
+	MsgHello    = "hello"    // client -> server: join with a display name
+	MsgWelcome  = "welcome"  // server -> client: assigned racer ID
+	MsgState    = "state"    // server -> clients: lobby and progress of every racer
+	MsgStart    = "start"    // server -> clients: a race begins with the given text
+	MsgProgress = "progress" // client -> server: runes typed, live WPM and whether finished
+	MsgResults  = "results"  // server -> clients: final placement once everyone finished
+)
+
+// Message is the envelope for everything sent over the wire
+type Message struct {
+	Type      string  `json:"type"`
+	ID        int     `json:"id,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Plugin    string  `json:"plugin,omitempty"`
+	Text      string  `json:"text,omitempty"`
+	SourceURL string  `json:"source_url,omitempty"`
+	Code      bool    `json:"code,omitempty"`   // The text is source code, typed with its layout
+	Length    int     `json:"length,omitempty"` // Runes in the race text
+	Progress  int     `json:"progress,omitempty"`
+	WPM       float64 `json:"wpm,omitempty"`
+	Done      bool    `json:"done,omitempty"`
+	Racers    []Racer `json:"racers,omitempty"`
+}
+
+// Racer is one participant as seen by everyone in the room
+type Racer struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Racing   bool    `json:"racing"` // False for spectators who joined mid-race
+	Progress int     `json:"progress"`
+	WPM      float64 `json:"wpm"`
+	Finished bool    `json:"finished"`        // Done with the race, whether or not they typed it all
+	Place    int     `json:"place,omitempty"` // From 1, and 0 for racers who gave up
+}
+
+// sortRacers orders placed racers by place, then everyone else by progress
+func sortRacers(racers []Racer) {
+	sort.Slice(racers, func(i, j int) bool {
+		a, b := racers[i], racers[j]
+		if (a.Place > 0) != (b.Place > 0) {
+			return a.Place > 0
+		}
+		if a.Place > 0 {
+			return a.Place < b.Place
+		}
+		if a.Progress != b.Progress {
+			return a.Progress > b.Progress
+		}
+		return a.ID < b.ID
+	})
+}
+
+// LocalAddrs lists the non-loopback IPv4 addresses of this machine with the
+// given port, for showing others where to join
+func LocalAddrs(port string) []string {
+	ifaces, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	var addrs []string
+	for _, addr := range ifaces {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.To4() == nil {
+			continue
+		}
+		addrs = append(addrs, net.JoinHostPort(ipNet.IP.String(), port))
+	}
+	return addrs
+}
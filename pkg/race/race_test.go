@@ -0,0 +1,242 @@
+package race
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go-racer/pkg/plugins"
+)
+
+type stubSource struct {
+	code bool
+}
+
+func (stubSource) Name() string        { return "Stub" }
+func (stubSource) Description() string { return "Test content" }
+func (s stubSource) GetContent(context.Context) (*plugins.Content, error) {
+	if s.code {
+		return &plugins.Content{Text: "func main() {\n\tgo()\n}", Code: true}, nil
+	}
+	return &plugins.Content{Text: "hello  world", SourceURL: "https://example.com"}, nil
+}
+
+func startServer(t *testing.T) (*Server, string) {
+	return startServerWith(t, stubSource{})
+}
+
+func startServerWith(t *testing.T, src plugins.ContentSource) (*Server, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(src)
+	srv.Interval = 20 * time.Millisecond
+	srv.Prepare = func(content *plugins.Content) {
+		if content.Code {
+			content.Text = strings.ReplaceAll(content.Text, "\t", "    ")
+			return
+		}
+		content.Text = strings.Join(strings.Fields(content.Text), " ")
+	}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return srv, ln.Addr().String()
+}
+
+// waitFor returns the next message of the given type that satisfies ok
+func waitFor(t *testing.T, c *Client, msgType string, ok func(Message) bool) Message {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case msg, open := <-c.Messages:
+			if !open {
+				t.Fatalf("connection closed while waiting for %q", msgType)
+			}
+			if msg.Type == msgType && (ok == nil || ok(msg)) {
+				return msg
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q", msgType)
+		}
+	}
+}
+
+func TestRace(t *testing.T) {
+	srv, addr := startServer(t)
+
+	alice, err := Dial(addr, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Close()
+	bob, err := Dial(addr, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bob.Close()
+
+	if alice.ID == bob.ID {
+		t.Fatal("racers should get distinct IDs")
+	}
+
+	// Both racers show up in the lobby
+	waitFor(t, alice, MsgState, func(m Message) bool { return len(m.Racers) == 2 })
+
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []*Client{alice, bob} {
+		start := waitFor(t, c, MsgStart, nil)
+		if start.Text != "hello world" {
+			t.Errorf("race text = %q, want the host's prepared text", start.Text)
+		}
+		if start.Length != 11 || start.Plugin != "Stub" || start.SourceURL != "https://example.com" {
+			t.Errorf("unexpected start message: %+v", start)
+		}
+	}
+
+	// Progress is relayed to the other racers
+	if err := bob.SendProgress(5, 60, false); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, alice, MsgState, func(m Message) bool {
+		for _, r := range m.Racers {
+			if r.ID == bob.ID && r.Progress == 5 && r.WPM == 60 {
+				return true
+			}
+		}
+		return false
+	})
+
+	if err := bob.SendProgress(11, 70, true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, alice, MsgState, func(m Message) bool { return m.Racers[0].ID == bob.ID && m.Racers[0].Finished })
+	if err := alice.SendProgress(11, 50, true); err != nil {
+		t.Fatal(err)
+	}
+
+	results := waitFor(t, alice, MsgResults, nil)
+	if len(results.Racers) != 2 {
+		t.Fatalf("got %d racers in results, want 2", len(results.Racers))
+	}
+	if first := results.Racers[0]; first.Name != "bob" || first.Place != 1 {
+		t.Errorf("first place = %+v, want bob", first)
+	}
+	if second := results.Racers[1]; second.Name != "alice" || second.Place != 2 {
+		t.Errorf("second place = %+v, want alice", second)
+	}
+}
+
+func TestRace_DisconnectEndsRace(t *testing.T) {
+	srv, addr := startServer(t)
+
+	alice, err := Dial(addr, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Close()
+	bob, err := Dial(addr, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, alice, MsgState, func(m Message) bool { return len(m.Racers) == 2 })
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, alice, MsgStart, nil)
+
+	if err := alice.SendProgress(11, 50, true); err != nil {
+		t.Fatal(err)
+	}
+	bob.Close()
+
+	results := waitFor(t, alice, MsgResults, nil)
+	if len(results.Racers) != 1 || results.Racers[0].Place != 1 {
+		t.Errorf("unexpected results after a racer left: %+v", results.Racers)
+	}
+}
+
+func TestRace_GivingUpIsNotPlaced(t *testing.T) {
+	srv, addr := startServer(t)
+
+	alice, err := Dial(addr, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Close()
+	bob, err := Dial(addr, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bob.Close()
+
+	waitFor(t, alice, MsgState, func(m Message) bool { return len(m.Racers) == 2 })
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, alice, MsgStart, nil)
+
+	// Bob gives up after one keystroke, before alice finishes
+	if err := bob.SendProgress(1, 90, true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, alice, MsgState, func(m Message) bool {
+		for _, r := range m.Racers {
+			if r.ID == bob.ID && r.Finished {
+				return true
+			}
+		}
+		return false
+	})
+	if err := alice.SendProgress(11, 50, true); err != nil {
+		t.Fatal(err)
+	}
+
+	results := waitFor(t, alice, MsgResults, nil)
+	if len(results.Racers) != 2 {
+		t.Fatalf("got %d racers in results, want 2", len(results.Racers))
+	}
+	if first := results.Racers[0]; first.Name != "alice" || first.Place != 1 {
+		t.Errorf("first place = %+v, want alice", first)
+	}
+	if quit := results.Racers[1]; quit.Name != "bob" || !quit.Finished || quit.Place != 0 {
+		t.Errorf("bob = %+v, want finished without a place", quit)
+	}
+}
+
+func TestRace_Code(t *testing.T) {
+	srv, addr := startServerWith(t, stubSource{code: true})
+
+	alice, err := Dial(addr, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Close()
+	waitFor(t, alice, MsgState, func(m Message) bool { return len(m.Racers) == 1 })
+
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	start := waitFor(t, alice, MsgStart, nil)
+	if !start.Code || start.Text != "func main() {\n    go()\n}" {
+		t.Errorf("start = %+v, want the code with its layout and the code flag", start)
+	}
+	if start.Length != 24 {
+		t.Errorf("Length = %d, want the runes of the prepared text", start.Length)
+	}
+}
+
+func TestStart_NoRacers(t *testing.T) {
+	srv, _ := startServer(t)
+	if err := srv.Start(context.Background()); err == nil {
+		t.Error("expected an error when starting an empty room")
+	}
+}
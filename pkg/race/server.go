@@ -0,0 +1,292 @@
+package race
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go-racer/pkg/plugins"
+)
+
+// DefaultInterval is how often the server broadcasts the state of the room
+const DefaultInterval = 200 * time.Millisecond
+
+const writeTimeout = 2 * time.Second
+
+// Server hosts a race room. The host picks the text through its ContentSource
+// and the server relays everyone's progress.
+type Server struct {
+	Source   plugins.ContentSource
+	Prepare  func(content *plugins.Content) // Optional filter applied to the text before it is sent
+	Interval time.Duration
+
+	mu       sync.Mutex
+	peers    map[int]*peer
+	nextID   int
+	length   int
+	running  bool
+	finished int
+	ln       net.Listener
+	done     chan struct{}
+	closed   bool
+}
+
+type peer struct {
+	conn  net.Conn
+	wmu   sync.Mutex
+	enc   *json.Encoder
+	racer Racer
+}
+
+func (p *peer) send(msg Message) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+	_ = p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return p.enc.Encode(msg)
+}
+
+// NewServer creates a server that takes race texts from src
+func NewServer(src plugins.ContentSource) *Server {
+	return &Server{
+		Source:   src,
+		Interval: DefaultInterval,
+		peers:    make(map[int]*peer),
+		done:     make(chan struct{}),
+	}
+}
+
+// Serve accepts racers on ln until the server is closed
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+
+	go s.broadcastLoop()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+				return err
+			}
+		}
+		go s.handle(conn)
+	}
+}
+
+// Close stops accepting racers and disconnects everyone
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	close(s.done)
+
+	for _, p := range s.peers {
+		p.conn.Close()
+	}
+	if s.ln != nil {
+		return s.ln.Close()
+	}
+	return nil
+}
+
+// Start fetches a new text and begins a race for everyone currently connected
+func (s *Server) Start(ctx context.Context) error {
+	content, err := s.Source.GetContent(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch race text: %w", err)
+	}
+
+	if s.Prepare != nil {
+		s.Prepare(content)
+	}
+	text := content.Text
+	if text == "" {
+		return errors.New("race text is empty")
+	}
+
+	s.mu.Lock()
+	if len(s.peers) == 0 {
+		s.mu.Unlock()
+		return errors.New("no racers connected")
+	}
+	length := utf8.RuneCountInString(text)
+	s.length = length
+	s.running = true
+	s.finished = 0
+	for _, p := range s.peers {
+		p.racer = Racer{ID: p.racer.ID, Name: p.racer.Name, Racing: true}
+	}
+	peers := s.snapshot()
+	s.mu.Unlock()
+
+	msg := Message{
+		Type:      MsgStart,
+		Plugin:    s.Source.Name(),
+		Text:      text,
+		SourceURL: content.SourceURL,
+		Code:      content.Code,
+		Length:    length,
+	}
+	for _, p := range peers {
+		_ = p.send(msg)
+	}
+	s.broadcast(MsgState)
+	return nil
+}
+
+// Racers returns every connected racer, leaders first
+func (s *Server) Racers() []Racer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.racers()
+}
+
+func (s *Server) racers() []Racer {
+	racers := make([]Racer, 0, len(s.peers))
+	for _, p := range s.peers {
+		racers = append(racers, p.racer)
+	}
+	sortRacers(racers)
+	return racers
+}
+
+func (s *Server) snapshot() []*peer {
+	peers := make([]*peer, 0, len(s.peers))
+	for _, p := range s.peers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+
+	var hello Message
+	if err := dec.Decode(&hello); err != nil || hello.Type != MsgHello {
+		return
+	}
+
+	p := &peer{conn: conn, enc: json.NewEncoder(conn)}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.nextID++
+	id := s.nextID
+	p.racer = Racer{ID: id, Name: hello.Name}
+	s.peers[id] = p
+	s.mu.Unlock()
+
+	if err := p.send(Message{Type: MsgWelcome, ID: id}); err != nil {
+		s.remove(id)
+		return
+	}
+
+	for {
+		var msg Message
+		if err := dec.Decode(&msg); err != nil {
+			break
+		}
+		if msg.Type == MsgProgress {
+			s.progress(id, msg)
+		}
+	}
+	s.remove(id)
+}
+
+func (s *Server) progress(id int, msg Message) {
+	s.mu.Lock()
+	p, ok := s.peers[id]
+	if !ok || !s.running || !p.racer.Racing || p.racer.Finished {
+		s.mu.Unlock()
+		return
+	}
+
+	p.racer.Progress = msg.Progress
+	p.racer.WPM = msg.WPM
+	if msg.Done {
+		// Only racers who typed the whole text are placed, not those who gave up
+		p.racer.Finished = true
+		if msg.Progress >= s.length {
+			s.finished++
+			p.racer.Place = s.finished
+		}
+	}
+	ended := s.checkEnd()
+	s.mu.Unlock()
+
+	if ended {
+		s.broadcast(MsgResults)
+	}
+}
+
+func (s *Server) remove(id int) {
+	s.mu.Lock()
+	delete(s.peers, id)
+	ended := s.checkEnd()
+	s.mu.Unlock()
+
+	if ended {
+		s.broadcast(MsgResults)
+	}
+}
+
+// checkEnd marks the race as over once every racer has finished. Callers must hold s.mu.
+func (s *Server) checkEnd() bool {
+	if !s.running {
+		return false
+	}
+	for _, p := range s.peers {
+		if p.racer.Racing && !p.racer.Finished {
+			return false
+		}
+	}
+	s.running = false
+	return true
+}
+
+func (s *Server) broadcast(msgType string) {
+	s.mu.Lock()
+	msg := Message{Type: msgType, Length: s.length, Racers: s.racers()}
+	peers := s.snapshot()
+	s.mu.Unlock()
+
+	for _, p := range peers {
+		if err := p.send(msg); err != nil {
+			p.conn.Close() // The reader loop notices and removes the racer
+		}
+	}
+}
+
+func (s *Server) broadcastLoop() {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.broadcast(MsgState)
+		}
+	}
+}
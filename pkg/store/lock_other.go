@@ -0,0 +1,14 @@
+//go:build aix || solaris || !(unix || windows)
+
+package store
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// lock fails where the store has no way to lock files, rather than let two
+// instances interleave their writes to the history
+func lock(path string) (func(), error) {
+	return nil, fmt.Errorf("cannot lock %s: file locking is not supported on %s", path, runtime.GOOS)
+}
@@ -0,0 +1,24 @@
+//go:build unix && !aix && !solaris
+
+package store
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock takes an exclusive advisory lock on path, blocking until it is free
+func lock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
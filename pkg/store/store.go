@@ -0,0 +1,294 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go-racer/pkg/config"
+)
+
+// SchemaVersion is the current layout of the data directory
+const SchemaVersion = 1
+
+const (
+	metaFileName = "meta.json"
+	runsFileName = "runs.jsonl"
+	lockFileName = "lock"
+)
+
+// ErrNewerSchema is returned when the data directory was written by a newer go-racer
+var ErrNewerSchema = errors.New("data was written by a newer version of go-racer")
+
+type meta struct {
+	SchemaVersion int `json:"schema_version"`
+}
+
+// record is one line of the run log. Runs carry their own character stats;
+// a metrics-only record holds totals imported from the legacy config.
+type record struct {
+	Run     *config.GameResult           `json:"run,omitempty"`
+	Metrics map[string]config.CharMetric `json:"metrics,omitempty"`
+}
+
+// Store keeps run history in an append-only JSON lines log. Appends take an
+// exclusive file lock and first read anything other instances appended, so
+// concurrent go-racer processes never overwrite each other.
+type Store struct {
+	dir string
+
+	mu      sync.Mutex
+	offset  int64 // Bytes of the log consumed so far
+	history []config.GameResult
+	metrics map[string]config.CharMetric
+}
+
+// OpenDefault opens the store in the user's data directory, importing the
+// legacy ~/.go-racer.json on first use
+func OpenDefault() (*Store, error) {
+	dir, err := config.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := config.LegacyPath()
+	if err != nil {
+		return nil, err
+	}
+	return Open(dir, legacy)
+}
+
+// Open opens or creates the store in dir. When the store is new and
+// legacyPath names an existing single-file config, its history and metrics
+// are imported. Pass an empty legacyPath to skip the migration.
+func Open(dir, legacyPath string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir:     dir,
+		metrics: make(map[string]config.CharMetric),
+	}
+
+	unlock, err := lock(filepath.Join(dir, lockFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	m, err := s.readMeta()
+	if os.IsNotExist(err) {
+		if err := s.create(legacyPath); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if m.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("%w (schema %d, this build understands %d)", ErrNewerSchema, m.SchemaVersion, SchemaVersion)
+	}
+
+	if err := s.readNew(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// History returns every recorded run, oldest first
+func (s *Store) History() []config.GameResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]config.GameResult(nil), s.history...)
+}
+
+// Metrics returns per-character totals across all runs
+func (s *Store) Metrics() map[string]config.CharMetric {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics := make(map[string]config.CharMetric, len(s.metrics))
+	for char, metric := range s.metrics {
+		metrics[char] = metric
+	}
+	return metrics
+}
+
+// Append records a finished run
+func (s *Store) Append(result config.GameResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lock(filepath.Join(s.dir, lockFileName))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Pick up runs other instances appended since we last looked
+	if err := s.readNew(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record{Run: &result})
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	f, err := os.OpenFile(s.runsPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// A crash mid-append can leave a line without its newline. Terminate it
+	// so the fragment is skipped as one bad line rather than merged with ours.
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > s.offset {
+		data = append([]byte{'\n'}, data...)
+	}
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	s.offset = info.Size() + int64(len(data))
+	s.add(record{Run: &result})
+	return nil
+}
+
+func (s *Store) runsPath() string {
+	return filepath.Join(s.dir, runsFileName)
+}
+
+func (s *Store) readMeta() (meta, error) {
+	var m meta
+	data, err := os.ReadFile(filepath.Join(s.dir, metaFileName))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to parse %s: %w", metaFileName, err)
+	}
+	return m, nil
+}
+
+// create initialises a new store, importing run data from the legacy config.
+// The log is written before the schema marker, so an interrupted migration
+// is simply redone on the next start.
+func (s *Store) create(legacyPath string) error {
+	var buf bytes.Buffer
+	if legacyPath != "" {
+		records, err := readLegacy(legacyPath)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(&buf)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+	}
+	if err := config.WriteFileAtomic(s.runsPath(), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(meta{SchemaVersion: SchemaVersion})
+	if err != nil {
+		return err
+	}
+	return config.WriteFileAtomic(filepath.Join(s.dir, metaFileName), data, 0644)
+}
+
+// readLegacy converts the history and metrics of a pre-split config into log records
+func readLegacy(path string) ([]record, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var legacy config.Legacy
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var records []record
+	if len(legacy.Metrics) > 0 {
+		records = append(records, record{Metrics: legacy.Metrics})
+	}
+	for i := range legacy.History {
+		records = append(records, record{Run: &legacy.History[i]})
+	}
+	return records, nil
+}
+
+// readNew consumes complete lines appended to the log since the last read.
+// Lines that fail to parse are skipped, and a trailing partial line is left
+// for a later read.
+func (s *Store) readNew() error {
+	f, err := os.Open(s.runsPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(s.offset, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return nil
+		}
+		line := data[:i]
+		data = data[i+1:]
+		s.offset += int64(i + 1)
+
+		var r record
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &r) != nil {
+			continue
+		}
+		s.add(r)
+	}
+}
+
+func (s *Store) add(r record) {
+	for char, metric := range r.Metrics {
+		s.addMetric(char, metric)
+	}
+	if r.Run == nil {
+		return
+	}
+	for char, metric := range r.Run.CharStats {
+		s.addMetric(char, metric)
+	}
+	s.history = append(s.history, *r.Run)
+}
+
+func (s *Store) addMetric(char string, metric config.CharMetric) {
+	existing := s.metrics[char]
+	existing.Attempts += metric.Attempts
+	existing.Mistakes += metric.Mistakes
+	s.metrics[char] = existing
+}
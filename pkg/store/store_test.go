@@ -0,0 +1,161 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-racer/pkg/config"
+)
+
+func run(wpm float64, char string) config.GameResult {
+	return config.GameResult{
+		WPM:       wpm,
+		Accuracy:  100,
+		Timestamp: int64(wpm),
+		CharStats: map[string]config.CharMetric{char: {Attempts: 2, Mistakes: 1}},
+	}
+}
+
+func TestAppendAndReopen(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(run(40, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(run(50, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := s.History()
+	if len(history) != 2 || history[0].WPM != 40 || history[1].WPM != 50 {
+		t.Errorf("History() = %+v, want runs at 40 and 50 WPM", history)
+	}
+	if got := s.Metrics()["a"]; got != (config.CharMetric{Attempts: 4, Mistakes: 2}) {
+		t.Errorf("Metrics()[a] = %+v, want 4 attempts and 2 mistakes", got)
+	}
+}
+
+func TestConcurrentInstances(t *testing.T) {
+	dir := t.TempDir()
+
+	a, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Append(run(40, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Append(run(50, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	// b picks up a's run before appending its own
+	if got := len(b.History()); got != 2 {
+		t.Errorf("second instance has %d runs, want 2", got)
+	}
+
+	s, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.History()); got != 2 {
+		t.Errorf("reopened store has %d runs, want 2", got)
+	}
+}
+
+func TestPartialLineIsSkipped(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(run(40, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash halfway through another instance's append
+	f, err := os.OpenFile(filepath.Join(dir, runsFileName), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"run":{"wpm":9`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := s.Append(run(50, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := s.History()
+	if len(history) != 2 || history[1].WPM != 50 {
+		t.Errorf("History() = %+v, want runs at 40 and 50 WPM", history)
+	}
+}
+
+func TestLegacyImport(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "legacy.json")
+	data := `{
+  "last_plugin": "github",
+  "metrics": {"x": {"attempts": 3, "mistakes": 1}},
+  "history": [{"wpm": 42, "accuracy": 97, "timestamp": 1}]
+}`
+	if err := os.WriteFile(legacy, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(filepath.Join(dir, "data"), legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history := s.History(); len(history) != 1 || history[0].WPM != 42 {
+		t.Errorf("History() = %+v, want the imported run", history)
+	}
+	if got := s.Metrics()["x"]; got != (config.CharMetric{Attempts: 3, Mistakes: 1}) {
+		t.Errorf("Metrics()[x] = %+v, want the imported totals", got)
+	}
+
+	// The import happens once; later runs are not duplicated on reopen
+	if err := s.Append(run(50, "x")); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(filepath.Join(dir, "data"), legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.History()); got != 2 {
+		t.Errorf("reopened store has %d runs, want 2", got)
+	}
+}
+
+func TestNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, metaFileName), []byte(`{"schema_version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(dir, ""); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Open() error = %v, want ErrNewerSchema", err)
+	}
+}
@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"strings"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+	"go-racer/pkg/plugins"
+)
+
+const defaultTabWidth = 4
+
+// tabWidth is how many spaces a tab in code is typed as
+func tabWidth(cfg *config.Config) int {
+	if cfg.TabWidth > 0 {
+		return cfg.TabWidth
+	}
+	return defaultTabWidth
+}
+
+// PrepareContent filters a plugin's text for typing. Code keeps its layout,
+// other text is flattened to single spaces.
+func PrepareContent(content *plugins.Content, cfg *config.Config) {
+	if content.Code {
+		content.Text = game.ApplyCodeFilters(content.Text, tabWidth(cfg))
+		return
+	}
+	content.Text = game.ApplyFilters(content.Text, cfg)
+}
+
+// isCode reports whether a test is drawn as code. Replays are rebuilt from
+// the text alone, and only code keeps its line breaks.
+func isCode(g *game.TypingTest) bool {
+	return g.Code || strings.Contains(g.TargetText, "\n")
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/plugins"
+)
+
+// codeModel is a model that has just loaded a piece of code
+func codeModel(t *testing.T, text string, autoIndent bool) Model {
+	t.Helper()
+	cfg := config.Default()
+	cfg.TabWidth = 2
+	cfg.AutoIndent = autoIndent
+
+	content := &plugins.Content{Text: "if x {\n\ty()\n}", Code: true}
+	PrepareContent(content, cfg)
+	if content.Text != text {
+		t.Fatalf("prepared text = %q, want %q", content.Text, text)
+	}
+
+	next, _ := Model{Plugin: &stubSource{}, Config: cfg}.Update(contentMsg{content: content})
+	return next.(Model)
+}
+
+func TestCodeMode_EnterAndTab(t *testing.T) {
+	m := codeModel(t, "if x {\n  y()\n}", false)
+	enter, tab := tea.KeyMsg{Type: tea.KeyEnter}, tea.KeyMsg{Type: tea.KeyTab}
+
+	m = press(t, m, runes("if"), tea.KeyMsg{Type: tea.KeySpace}, runes("x"), tea.KeyMsg{Type: tea.KeySpace}, runes("{"), enter)
+	if strings.Contains(m.renderGame(), "↵") {
+		t.Error("expected no line break marker after typing it correctly")
+	}
+
+	m = press(t, m, tab, runes("y()"))
+	if m.Game.UserInput != "if x {\n  y()" {
+		t.Fatalf("UserInput = %q, want Enter as a newline and Tab as spaces", m.Game.UserInput)
+	}
+
+	// The cursor sits on the next line break, which is drawn so it can be seen
+	view := m.renderGame()
+	if !strings.Contains(view, "↵") {
+		t.Error("expected the line break at the cursor to be drawn")
+	}
+	if !strings.Contains(view, "\n}") {
+		t.Error("expected the code to keep its lines")
+	}
+}
+
+func TestCodeMode_AutoIndent(t *testing.T) {
+	m := codeModel(t, "if x {\n  y()\n}", true)
+
+	m = press(t, m, runes("if x {"), tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Game.UserInput != "if x {\n  " {
+		t.Errorf("UserInput = %q, want the indentation skipped", m.Game.UserInput)
+	}
+}
+
+func TestProseIgnoresEnter(t *testing.T) {
+	next, _ := Model{Plugin: &stubSource{}, Config: config.Default()}.Update(contentMsg{content: &plugins.Content{Text: "a b"}})
+	m := press(t, next.(Model), runes("a"), tea.KeyMsg{Type: tea.KeyEnter})
+	if m.Game.UserInput != "a" {
+		t.Errorf("UserInput = %q, want Enter ignored outside code", m.Game.UserInput)
+	}
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+)
+
+// newGhost picks the opponent for a test on text according to the ghost setting
+func (m Model) newGhost(text string) *game.Ghost {
+	switch m.Config.Ghost {
+	case config.GhostBest:
+		if run, ok := bestRun(m.History, text); ok {
+			return game.NewRecordedGhost(text, run.Keystrokes, run.WPM)
+		}
+		if m.Config.GhostWPM > 0 {
+			return &game.Ghost{Text: text, WPM: float64(m.Config.GhostWPM)}
+		}
+	case config.GhostPace:
+		return &game.Ghost{Text: text, WPM: float64(m.Config.GhostWPM)}
+	}
+	return nil
+}
+
+// bestRun returns the fastest recorded run that typed all of text
+func bestRun(history []config.GameResult, text string) (config.GameResult, bool) {
+	var best config.GameResult
+	found := false
+	for _, run := range history {
+		if run.Text != text || len(run.Keystrokes) == 0 || (found && run.WPM <= best.WPM) {
+			continue
+		}
+		if !game.Replay(run.Text, run.Keystrokes, run.Duration()).IsFinished() {
+			continue
+		}
+		best = run
+		found = true
+	}
+	return best, found
+}
+
+func ghostLabel(g *game.Ghost) string {
+	if g.IsRecorded() {
+		return fmt.Sprintf("Ghost (PB %.0f WPM)", g.WPM)
+	}
+	return fmt.Sprintf("Ghost (%.0f WPM)", g.WPM)
+}
+
+// renderGhostStatus says how far ahead or behind the ghost the typist is
+func (m Model) renderGhostStatus() string {
+	lead := m.Game.Position() - m.Ghost.Position(m.Game.Elapsed())
+	switch {
+	case lead > 0:
+		return CorrectStyle.Render(fmt.Sprintf("%s: you are %d chars ahead", ghostLabel(m.Ghost), lead))
+	case lead < 0:
+		return ErrorStyle.Render(fmt.Sprintf("%s: you are %d chars behind", ghostLabel(m.Ghost), -lead))
+	default:
+		return UntypedStyle.Render(ghostLabel(m.Ghost) + ": neck and neck")
+	}
+}
+
+// renderGhostGap reports the final gap to the ghost on the results screen
+func (m Model) renderGhostGap() string {
+	chars, seconds := m.Ghost.Gap(m.Game)
+	label := ghostLabel(m.Ghost)
+	switch {
+	case chars > 0 || (chars == 0 && seconds > 0):
+		return fmt.Sprintf("%s: beat you by %.2fs (%d chars)", label, math.Abs(seconds), chars)
+	case chars < 0 || seconds < 0:
+		return fmt.Sprintf("%s: you won by %.2fs (%d chars)", label, math.Abs(seconds), -chars)
+	default:
+		return label + ": dead heat"
+	}
+}
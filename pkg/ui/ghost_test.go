@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"go-racer/pkg/config"
+	"testing"
+	"time"
+)
+
+func finishedRun(text string, wpm float64) config.GameResult {
+	var log []config.Keystroke
+	at := time.Duration(0)
+	for _, r := range text {
+		at += 100 * time.Millisecond
+		log = append(log, config.Keystroke{Kind: config.KeystrokeInsert, Rune: string(r), At: at})
+	}
+	log = append(log, config.Keystroke{Kind: config.KeystrokeFinish, At: at})
+	return config.GameResult{WPM: wpm, Text: text, Keystrokes: log}
+}
+
+func TestNewGhost(t *testing.T) {
+	abandoned := finishedRun("go", 200)
+	abandoned.Keystrokes = abandoned.Keystrokes[:1]
+
+	cfg := &config.Config{Ghost: config.GhostBest, GhostWPM: 45}
+	m := Model{
+		Config: cfg,
+		History: []config.GameResult{
+			finishedRun("go", 40),
+			finishedRun("go", 70),
+			finishedRun("other", 90),
+			abandoned,
+		},
+	}
+
+	ghost := m.newGhost("go")
+	if ghost == nil || !ghost.IsRecorded() || ghost.WPM != 70 {
+		t.Errorf("expected the 70 WPM personal best, got %+v", ghost)
+	}
+
+	// Without a recorded run on the same text the target pace is used
+	ghost = m.newGhost("new text")
+	if ghost == nil || ghost.IsRecorded() || ghost.WPM != 45 {
+		t.Errorf("expected a 45 WPM pace ghost, got %+v", ghost)
+	}
+
+	cfg.Ghost = config.GhostOff
+	if m.newGhost("go") != nil {
+		t.Error("expected no ghost when turned off")
+	}
+}
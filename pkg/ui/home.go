@@ -0,0 +1,254 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/plugins"
+	"go-racer/pkg/store"
+)
+
+// homeState tracks the home screen, where a plugin is picked
+type homeState struct {
+	Entries []homeEntry // Every plugin, recent ones first
+	Query   string      // Fuzzy search typed by the user
+	Cursor  int         // Selected row of the filtered list
+}
+
+// homeEntry is one plugin as shown on the home screen. Only the
+// registration is used, so listing plugins doesn't create them.
+type homeEntry struct {
+	Reg    plugins.Registration
+	Recent bool
+}
+
+// InitialHomeModel creates a model that starts on the home screen and only
+// fetches content once a plugin has been picked
+func InitialHomeModel(cfg *config.Config, st *store.Store) Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	m := Model{
+		Spinner: s,
+		Config:  cfg,
+	}
+	m.attachStore(st)
+	next, _ := m.openHome()
+	return next.(Model)
+}
+
+// openHome shows the home screen with the current plugin selected
+func (m Model) openHome() (tea.Model, tea.Cmd) {
+	m.ShowHome = true
+	m.Home = homeState{Entries: homeEntries(m.Config.RecentPlugins)}
+
+	current := m.CurrentPluginName
+	if current == "" {
+		current = m.Config.LastPlugin
+	}
+	for i, e := range m.Home.Entries {
+		if e.Reg.Name == current {
+			m.Home.Cursor = i
+		}
+	}
+	return m, nil
+}
+
+// homeEntries lists every registered plugin, recently used ones first
+func homeEntries(recent []string) []homeEntry {
+	rank := make(map[string]int)
+	for i, name := range recent {
+		rank[name] = i + 1
+	}
+
+	var entries []homeEntry
+	for _, r := range plugins.Registrations() {
+		entries = append(entries, homeEntry{Reg: r, Recent: rank[r.Name] > 0})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		ri, rj := rank[entries[i].Reg.Name], rank[entries[j].Reg.Name]
+		if ri == 0 || rj == 0 {
+			return ri > rj // Recent plugins before the rest
+		}
+		return ri < rj
+	})
+	return entries
+}
+
+// filtered returns the entries matching the query, best matches first
+func (h homeState) filtered() []homeEntry {
+	if h.Query == "" {
+		return h.Entries
+	}
+
+	type match struct {
+		entry homeEntry
+		score int
+	}
+	var matches []match
+	for _, e := range h.Entries {
+		best := -1
+		for _, target := range append([]string{e.Reg.Name, e.Reg.Description, e.Reg.Category}, e.Reg.Aliases...) {
+			if score := fuzzyScore(h.Query, target); score > best {
+				best = score
+			}
+		}
+		if best >= 0 {
+			matches = append(matches, match{e, best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+
+	entries := make([]homeEntry, len(matches))
+	for i, match := range matches {
+		entries[i] = match.entry
+	}
+	return entries
+}
+
+// fuzzyScore reports how well query matches target as a case-insensitive
+// subsequence, or -1 if it does not. Consecutive characters and matches at
+// the start of a word score higher.
+func fuzzyScore(query, target string) int {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(target))
+
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 2
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]) {
+			score += 3
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return -1
+	}
+	return score
+}
+
+func (m Model) updateHome(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	entries := m.Home.filtered()
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		if m.Home.Query != "" {
+			m.Home.Query = ""
+			m.Home.Cursor = 0
+			return m, nil
+		}
+		if m.Plugin == nil {
+			// Nothing to go back to when the program started here
+			return m.quit()
+		}
+		m.ShowHome = false
+	case tea.KeyUp:
+		if m.Home.Cursor > 0 {
+			m.Home.Cursor--
+		}
+	case tea.KeyDown:
+		if m.Home.Cursor < len(entries)-1 {
+			m.Home.Cursor++
+		}
+	case tea.KeyEnter:
+		if m.Home.Cursor < len(entries) {
+			m.ShowHome = false
+			return m.selectPlugin(entries[m.Home.Cursor].Reg.Name)
+		}
+	case tea.KeyBackspace:
+		if q := []rune(m.Home.Query); len(q) > 0 {
+			m.Home.Query = string(q[:len(q)-1])
+			m.Home.Cursor = 0
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.Home.Query += string(msg.Runes)
+		m.Home.Cursor = 0
+	}
+	return m, nil
+}
+
+// selectPlugin switches to the named plugin and starts loading from it
+func (m Model) selectPlugin(name string) (tea.Model, tea.Cmd) {
+	p, err := plugins.GetConfiguredPlugin(name, m.Config.PluginOptions[name])
+	if err != nil {
+		m.Err = err
+		return m, nil
+	}
+	p = plugins.WithCache(name, p, m.Config.PluginOptions[name], m.CacheDir)
+
+	m.Plugin = p
+	m.CurrentPluginName = name
+	m.PluginSettings = pluginSettingsState{}
+
+	// Save config
+	m.Config.UsePlugin(name)
+	_ = config.Save(m.Config)
+
+	return m.reload()
+}
+
+func (m Model) renderHome() string {
+	var s strings.Builder
+	s.WriteString(TitleStyle.Render("Go Racer"))
+	s.WriteString("\n\n")
+
+	s.WriteString("Search: " + m.Home.Query + CursorStyle.Render(" "))
+	s.WriteString("\n\n")
+
+	entries := m.Home.filtered()
+	if len(entries) == 0 {
+		s.WriteString(UntypedStyle.Render("  No plugins match"))
+		s.WriteString("\n")
+	}
+
+	for i, e := range entries {
+		marker := "  "
+		if i == m.Home.Cursor {
+			marker = "> "
+		}
+
+		var tags []string
+		if e.Recent {
+			tags = append(tags, "recent")
+		}
+		for _, tag := range []string{e.Reg.Category, e.Reg.Language} {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		if e.Reg.Online {
+			tags = append(tags, "needs network")
+		} else {
+			tags = append(tags, "offline")
+		}
+
+		title := marker + e.Reg.Name
+		if len(e.Reg.Aliases) > 0 {
+			title += " (" + strings.Join(e.Reg.Aliases, ", ") + ")"
+		}
+		if i == m.Home.Cursor {
+			title = CorrectStyle.Render(title)
+		}
+		s.WriteString(title + "  " + UntypedStyle.Render("["+strings.Join(tags, ", ")+"]") + "\n")
+		s.WriteString("    " + UntypedStyle.Render(e.Reg.Description) + "\n")
+	}
+
+	s.WriteString("\n")
+	s.WriteString(UntypedStyle.Render("Type to search  ↑/↓: select  Enter: start  Esc: back"))
+	return s.String()
+}
@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+	"go-racer/pkg/plugins"
+)
+
+// homeTestFactoryCalls counts how often the home-test plugin was created
+var homeTestFactoryCalls int
+
+func init() {
+	plugins.Register(plugins.Registration{
+		Name:        "home-test",
+		Description: "Only used by the home screen tests",
+		Category:    "testing",
+		Factory: func() plugins.ContentSource {
+			homeTestFactoryCalls++
+			return &stubSource{items: []string{"home test"}}
+		},
+	})
+}
+
+func TestHome_RecentPluginsFirst(t *testing.T) {
+	cfg := config.Default()
+	cfg.RecentPlugins = []string{"spanish-news", "hn"}
+
+	m := InitialHomeModel(cfg, nil)
+	if !m.ShowHome || m.IsLoading || m.Init() != nil {
+		t.Fatal("expected to wait on the home screen without fetching")
+	}
+
+	entries := m.Home.filtered()
+	if len(entries) != len(plugins.Registrations()) {
+		t.Fatalf("home lists %d plugins, want every registered one", len(entries))
+	}
+	if entries[0].Reg.Name != "spanish-news" || entries[1].Reg.Name != "hn" {
+		t.Errorf("home starts with %q, %q, want the recent plugins in order", entries[0].Reg.Name, entries[1].Reg.Name)
+	}
+	if !entries[0].Recent || entries[2].Recent {
+		t.Error("only recent plugins should be marked recent")
+	}
+}
+
+func TestHome_OnlyCreatesThePick(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	homeTestFactoryCalls = 0
+
+	m := InitialHomeModel(config.Default(), nil)
+	view := m.View()
+	if !strings.Contains(view, "home-test") || !strings.Contains(view, "Only used by the home screen tests") || !strings.Contains(view, "testing") {
+		t.Errorf("home should list the plugin from its registration:\n%s", view)
+	}
+	if homeTestFactoryCalls != 0 {
+		t.Fatalf("listing plugins created home-test %d times, want none", homeTestFactoryCalls)
+	}
+
+	m = press(t, m, runes("home-test"))
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if homeTestFactoryCalls != 1 || m.CurrentPluginName != "home-test" {
+		t.Errorf("created home-test %d times and picked %q, want it created once when picked", homeTestFactoryCalls, m.CurrentPluginName)
+	}
+	if content, err := m.Plugin.GetContent(context.Background()); err != nil || content.Text != "home test" {
+		t.Errorf("GetContent() = %v, %v, want the picked plugin", content, err)
+	}
+}
+
+func TestHome_SearchAndPick(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := InitialHomeModel(config.Default(), nil)
+	m = press(t, m, runes("elp"))
+
+	entries := m.Home.filtered()
+	if len(entries) == 0 || entries[0].Reg.Name != "spanish-news" {
+		t.Fatalf("search for %q gave %v, want spanish-news first", m.Home.Query, entries)
+	}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ShowHome || m.CurrentPluginName != "spanish-news" || !m.IsLoading {
+		t.Errorf("home=%v plugin=%q loading=%v, want a load from spanish-news", m.ShowHome, m.CurrentPluginName, m.IsLoading)
+	}
+	if m.Config.LastPlugin != "spanish-news" || m.Config.RecentPlugins[0] != "spanish-news" {
+		t.Errorf("LastPlugin = %q, recent = %q, want the pick persisted", m.Config.LastPlugin, m.Config.RecentPlugins)
+	}
+}
+
+func TestHome_FromResults(t *testing.T) {
+	g := game.NewTypingTest("done")
+	g.IsComplete = true
+	m := Model{
+		Plugin:            plugins.NewHackerNewsSource(plugins.HTTPOptions{}),
+		CurrentPluginName: "hn",
+		Config:            config.Default(),
+		Game:              g,
+	}
+
+	m = press(t, m, runes("p"))
+	if !m.ShowHome {
+		t.Fatal("expected p to open the home screen")
+	}
+	if got := m.Home.filtered()[m.Home.Cursor].Reg.Name; got != "hn" {
+		t.Errorf("home starts on %q, want the current plugin", got)
+	}
+
+	// Esc goes back to the results rather than quitting
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.ShowHome || m.Quitting {
+		t.Errorf("home=%v quitting=%v, want back on the results", m.ShowHome, m.Quitting)
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query, target string
+		match         bool
+	}{
+		{"hn", "Hacker News", true},
+		{"gh", "github", true},
+		{"news", "spanish-news", true},
+		{"xyz", "Hacker News", false},
+		{"nh", "hn", false},
+	}
+	for _, tt := range tests {
+		if got := fuzzyScore(tt.query, tt.target) >= 0; got != tt.match {
+			t.Errorf("fuzzyScore(%q, %q) matched = %v, want %v", tt.query, tt.target, got, tt.match)
+		}
+	}
+
+	// Word starts beat scattered letters
+	if fuzzyScore("hn", "Hacker News") <= fuzzyScore("hn", "bahnhof") {
+		t.Error("expected initials to score higher than a mid-word match")
+	}
+}
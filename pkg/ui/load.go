@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const defaultFetchTimeout = 15 * time.Second
+
+// fetchTimeout is how long a plugin gets to provide content
+func (m Model) fetchTimeout() time.Duration {
+	if m.Config.FetchTimeout > 0 {
+		return time.Duration(m.Config.FetchTimeout) * time.Second
+	}
+	return defaultFetchTimeout
+}
+
+// beginLoad cancels any fetch in flight and prepares a new one. Results of
+// the cancelled fetch are dropped when they arrive.
+func (m *Model) beginLoad() {
+	m.cancelLoad()
+	m.loadID++
+	m.loadCtx, m.stopLoad = context.WithTimeout(context.Background(), m.fetchTimeout())
+	m.IsLoading = true
+	m.Err = nil
+}
+
+// cancelLoad abandons the fetch in flight, if any
+func (m *Model) cancelLoad() {
+	if m.stopLoad != nil {
+		m.stopLoad()
+		m.stopLoad = nil
+	}
+}
+
+// fetchContent runs the load prepared by beginLoad
+func (m Model) fetchContent() tea.Cmd {
+	ctx, id, timeout := m.loadCtx, m.loadID, m.fetchTimeout()
+	return func() tea.Msg {
+		switch msg := m.loadContent(ctx).(type) {
+		case contentMsg:
+			msg.loadID = id
+			return msg
+		case errorMsg:
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				msg.err = fmt.Errorf("timed out after %s: %w", timeout, msg.err)
+			}
+			msg.loadID = id
+			return msg
+		default:
+			return msg
+		}
+	}
+}
+
+// reload fetches a new text from the current plugin
+func (m Model) reload() (tea.Model, tea.Cmd) {
+	m.beginLoad()
+	return m, tea.Batch(m.Spinner.Tick, m.fetchContent())
+}
+
+// quit cancels any fetch in flight and exits
+func (m Model) quit() (tea.Model, tea.Cmd) {
+	m.cancelLoad()
+	m.Quitting = true
+	return m, tea.Quit
+}
+
+// updateLoading handles keys while content is loading
+func (m Model) updateLoading(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "q" || msg.Type == tea.KeyEsc:
+		return m.quit()
+	case msg.String() == "p" && m.Race == nil:
+		return m.openHome()
+	}
+	return m, nil
+}
+
+// updateError handles keys on the error screen
+func (m Model) updateError(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "q" || msg.Type == tea.KeyEsc:
+		return m.quit()
+	case msg.String() == "r" && m.Race == nil:
+		return m.reload()
+	case msg.String() == "p" && m.Race == nil:
+		return m.openHome()
+	}
+	return m, nil
+}
+
+func (m Model) renderError() string {
+	var s strings.Builder
+	if m.Race == nil && m.Plugin != nil {
+		s.WriteString(ErrorStyle.Render("Couldn't load text from " + m.Plugin.Name()))
+	} else {
+		s.WriteString(ErrorStyle.Render("Something went wrong"))
+	}
+	s.WriteString("\n\n")
+	s.WriteString(m.Err.Error())
+	s.WriteString("\n\n")
+
+	if m.Race == nil {
+		s.WriteString(UntypedStyle.Render("r: retry  p: switch plugin  q: quit"))
+	} else {
+		s.WriteString(UntypedStyle.Render("q: quit"))
+	}
+	return s.String()
+}
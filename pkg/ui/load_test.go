@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/plugins"
+)
+
+// blockingSource never returns content; it waits until the load is cancelled
+type blockingSource struct{}
+
+func (blockingSource) Name() string        { return "Blocking" }
+func (blockingSource) Description() string { return "Hangs until cancelled" }
+
+func (blockingSource) GetContent(ctx context.Context) (*plugins.Content, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestQuitCancelsLoad(t *testing.T) {
+	m := InitialModel(blockingSource{}, "blocking", config.Default(), nil)
+
+	done := make(chan tea.Msg, 1)
+	go func() { done <- m.fetchContent()() }()
+
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if !next.(Model).Quitting || cmd == nil {
+		t.Fatal("expected q to quit while loading")
+	}
+
+	select {
+	case msg := <-done:
+		if e, ok := msg.(errorMsg); !ok || !errors.Is(e.err, context.Canceled) {
+			t.Errorf("load returned %#v, want a cancellation error", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("load was not cancelled")
+	}
+}
+
+func TestStaleLoadIsDropped(t *testing.T) {
+	m := InitialModel(&stubSource{items: []string{"fresh"}}, "stub", config.Default(), nil)
+	stale := m.loadID
+
+	next, _ := m.reload()
+	m = next.(Model)
+
+	next, _ = m.Update(contentMsg{content: &plugins.Content{Text: "stale"}, loadID: stale})
+	m = next.(Model)
+	if m.Game != nil || !m.IsLoading {
+		t.Fatal("content from a superseded load was used")
+	}
+
+	next, _ = m.Update(m.fetchContent()())
+	m = next.(Model)
+	if m.Game == nil || m.Game.TargetText != "fresh" {
+		t.Errorf("expected the current load to start a test")
+	}
+}
+
+func TestErrorScreen(t *testing.T) {
+	m := InitialModel(&stubSource{items: []string{"text"}}, "stub", config.Default(), nil)
+	next, _ := m.Update(errorMsg{err: errors.New("boom"), loadID: m.loadID})
+	m = next.(Model)
+	if m.Err == nil || m.IsLoading {
+		t.Fatal("expected the load error to be shown")
+	}
+
+	// r retries the same plugin
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	retried := next.(Model)
+	if retried.Err != nil || !retried.IsLoading || cmd == nil {
+		t.Error("expected r to clear the error and load again")
+	}
+
+	// q quits
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if !next.(Model).Quitting {
+		t.Error("expected q to quit from the error screen")
+	}
+}
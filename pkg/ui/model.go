@@ -1,8 +1,9 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"sort"
 	"strings"
 	"time"
@@ -13,10 +14,13 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/muesli/reflow/wordwrap"
+	"github.com/rivo/uniseg"
 
 	"go-racer/pkg/config"
 	"go-racer/pkg/game"
 	"go-racer/pkg/plugins"
+	"go-racer/pkg/race"
+	"go-racer/pkg/store"
 )
 
 type Model struct {
@@ -28,32 +32,76 @@ type Model struct {
 	Spinner           spinner.Model
 	Quitting          bool
 	Config            *config.Config
+	Store             *store.Store // Where finished runs are recorded; nil keeps them in memory only
+	CacheDir          string       // Where online plugins keep their offline pool; empty disables caching
+	History           []config.GameResult
+	Metrics           map[string]config.CharMetric
 	ShowMetrics       bool
 	ShowSettings      bool
 	ShowTrend         bool
+	ShowReplay        bool
+	ShowHome          bool
+	Home              homeState
+	Replay            replayState
+	PluginSettings    pluginSettingsState
 	CurrentContent    *plugins.Content
+	Sources           []*plugins.Content // Every item stitched into the current test
+	Ghost             *game.Ghost        // Opponent paced from a past run or a target WPM
+	Race              *race.Client       // Set when taking part in a LAN race
+	RaceServer        *race.Server       // Set on the host, who starts each race
+	RaceAddrs         []string           // Addresses others can join the host on
+	Racers            []race.Racer
+	RaceLength        int
+	RaceOver          bool
+	RaceNotice        string
+	Status            string // Shown for a moment under the results, such as a link that wouldn't open
+	statusID          int    // Incremented per status so an older one's timer doesn't clear it
 	width             int
 	height            int
+	gameID            int       // Incremented per test so late background fetches can be dropped
+	fetchingMore      bool      // A timed test is pulling more content in the background
+	fetchRetryAt      time.Time // Earliest time to retry a failed background fetch
+	loadID            int       // Identifies the current content load so superseded results are dropped
+	loadCtx           context.Context
+	stopLoad          context.CancelFunc
 }
 
-func InitialModel(plugin plugins.ContentSource, pluginName string, cfg *config.Config) Model {
+func InitialModel(plugin plugins.ContentSource, pluginName string, cfg *config.Config, st *store.Store) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
-	return Model{
+	m := Model{
 		Plugin:            plugin,
 		CurrentPluginName: pluginName,
 		IsLoading:         true,
 		Spinner:           s,
 		Config:            cfg,
 	}
+	m.attachStore(st)
+	m.beginLoad()
+	return m
+}
+
+// attachStore loads run data from st, which is where finished runs are recorded from then on
+func (m *Model) attachStore(st *store.Store) {
+	m.Store = st
+	if st != nil {
+		m.History = st.History()
+		m.Metrics = st.Metrics()
+	}
 }
 
 func (m Model) Init() tea.Cmd {
+	if m.Race != nil {
+		return waitForRace(m.Race)
+	}
+	if !m.IsLoading {
+		return nil // Waiting on the home screen for a plugin to be picked
+	}
 	return tea.Batch(
 		m.Spinner.Tick,
-		m.loadContent,
+		m.fetchContent(),
 	)
 }
 
@@ -66,16 +114,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case tea.KeyMsg:
 		if msg.Type == tea.KeyCtrlC {
-			m.Quitting = true
-			return m, tea.Quit
+			return m.quit()
+		}
+
+		if m.ShowHome {
+			return m.updateHome(msg)
+		}
+
+		if m.Err != nil {
+			return m.updateError(msg)
+		}
+
+		if m.Race != nil && m.Game == nil {
+			return m.updateLobby(msg)
 		}
 
 		if m.IsLoading {
-			return m, nil
+			return m.updateLoading(msg)
 		}
 
 		if m.Game.IsComplete {
 			if m.ShowSettings {
+				if next, cmd, ok := m.updatePluginSettings(msg); ok {
+					return next, cmd
+				}
 				switch msg.String() {
 				case "esc", ",":
 					m.ShowSettings = false
@@ -91,20 +153,63 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				case "s":
 					m.Config.IncludeNonStandardChars = !m.Config.IncludeNonStandardChars
 					_ = config.Save(m.Config)
+				case "m":
+					switch m.Config.Mode {
+					case config.ModeTime:
+						m.Config.Mode = config.ModeWords
+					case config.ModeWords:
+						m.Config.Mode = config.ModeText
+					default:
+						m.Config.Mode = config.ModeTime
+					}
+					_ = config.Save(m.Config)
+				case "g":
+					switch m.Config.Ghost {
+					case config.GhostBest:
+						m.Config.Ghost = config.GhostPace
+					case config.GhostPace:
+						m.Config.Ghost = config.GhostOff
+					default:
+						m.Config.Ghost = config.GhostBest
+					}
+					_ = config.Save(m.Config)
+				case "w":
+					m.Config.GhostWPM = nextOption(game.GhostPaces, m.Config.GhostWPM)
+					_ = config.Save(m.Config)
+				case "t":
+					m.Config.TabWidth = nextOption(game.TabWidths, tabWidth(m.Config))
+					_ = config.Save(m.Config)
+				case "i":
+					m.Config.AutoIndent = !m.Config.AutoIndent
+					_ = config.Save(m.Config)
+				case "l":
+					switch m.Config.Mode {
+					case config.ModeTime:
+						m.Config.TimeLimit = nextOption(game.TimeLimits, m.Config.TimeLimit)
+					case config.ModeWords:
+						m.Config.WordCount = nextOption(game.WordCounts, m.Config.WordCount)
+					}
+					_ = config.Save(m.Config)
 				}
 				return m, nil
 			}
 
+			if m.ShowReplay {
+				return m.updateReplay(msg)
+			}
+
 			if msg.String() == "q" || msg.Type == tea.KeyEsc {
-				m.Quitting = true
-				return m, tea.Quit
+				return m.quit()
+			}
+			if m.Race != nil && (msg.String() == "r" || msg.String() == "p") {
+				// Only the host picks texts in a race
+				if msg.String() == "r" && m.RaceServer != nil {
+					return m, m.startRace()
+				}
+				return m, nil
 			}
 			if msg.String() == "r" {
-				m.IsLoading = true
-				return m, tea.Batch(
-					m.Spinner.Tick,
-					m.loadContent,
-				)
+				return m.reload()
 			}
 			if msg.String() == "," {
 				m.ShowSettings = !m.ShowSettings
@@ -123,6 +228,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			if msg.String() == "v" {
+				return m.startReplay(len(m.History) - 1)
+			}
+
 			if msg.String() == "t" {
 				m.ShowTrend = !m.ShowTrend
 				return m, nil
@@ -137,41 +246,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			if msg.String() == "p" {
-				// Switch plugin
-				nextPlugin := "github"
-				if m.CurrentPluginName == "github" {
-					nextPlugin = "hn"
-				}
-
-				p, err := plugins.GetPlugin(nextPlugin)
-				if err != nil {
-					m.Err = err
-					return m, nil
-				}
-
-				m.Plugin = p
-				m.CurrentPluginName = nextPlugin
-				m.IsLoading = true
-
-				// Save config
-				m.Config.LastPlugin = nextPlugin
-				_ = config.Save(m.Config)
-
-				return m, tea.Batch(
-					m.Spinner.Tick,
-					m.loadContent,
-				)
+				return m.openHome()
 			}
 
 			if msg.Type == tea.KeyEnter {
 				if m.CurrentContent != nil && m.CurrentContent.SourceURL != "" {
-					// Open URL
-					return m, tea.ExecProcess(exec.Command("open", m.CurrentContent.SourceURL), func(err error) tea.Msg {
-						if err != nil {
-							return errorMsg{err}
-						}
-						return nil
-					})
+					return m, openURL(m.CurrentContent.SourceURL)
+				}
+			}
+
+			// Number keys open the individual sources of a stitched test
+			if msg.Type == tea.KeyRunes && len(m.Sources) > 1 {
+				if n := int(msg.Runes[0] - '1'); n >= 0 && n < len(m.Sources) && n < 9 {
+					if url := m.Sources[n].SourceURL; url != "" {
+						return m, openURL(url)
+					}
 				}
 			}
 
@@ -181,9 +270,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Game logic input handling
 		switch msg.Type {
 		case tea.KeyEsc:
+			m.Game.Start() // Timed tests only start on the first keystroke
 			m.Game.Complete()
-			m.Game.CalculateStats()
-			m.saveMetrics()
 		case tea.KeyBackspace:
 			if msg.Alt {
 				m.Game.BackspaceWord()
@@ -193,37 +281,128 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlW:
 			m.Game.BackspaceWord()
 		case tea.KeyRunes:
-			// Handle space as a rune
-			m.Game.AddInput(msg.Runes[0])
+			// Multi-rune events carry emoji sequences, combining marks and pastes
+			for _, r := range msg.Runes {
+				m.Game.AddInput(r)
+			}
 		case tea.KeySpace:
 			m.Game.AddInput(' ')
+		case tea.KeyEnter:
+			if m.Game.Code {
+				m.Game.AddInput('\n')
+			}
+		case tea.KeyTab:
+			if m.Game.Code {
+				m.Game.TypeTab(tabWidth(m.Config))
+			}
 		}
 
 		// Verify completion after input
-		if len(m.Game.UserInput) >= len(m.Game.TargetText) {
+		if !m.Game.IsTimed() && m.Game.IsFinished() {
 			m.Game.Complete()
+		}
+		if m.Game.IsComplete {
 			// Stats need to be calculated one last time to be sure
 			m.Game.CalculateStats()
 			m.saveMetrics()
+			return m, m.reportProgress()
 		}
+		next, cmd := m.prefetch()
+		return next, tea.Batch(cmd, m.reportProgress())
 
 	case contentMsg:
+		if msg.loadID != m.loadID {
+			return m, nil // Superseded by a retry or plugin switch
+		}
+		m.cancelLoad()
 		m.IsLoading = false
 		m.Game = game.NewTypingTest(msg.content.Text)
+		m.Game.Code = msg.content.Code
+		m.Game.AutoIndent = msg.content.Code && m.Config.AutoIndent
 		m.CurrentContent = msg.content
+		m.Sources = msg.sources
 		m.Game.Start() // Start timer immediately on load? Or wait for first keypress?
 		// Let's modify game to start on first input in a future iteration if needed.
 		// For now, let's just start a timer but only count "active" time?
 		// Actually, Game.Start() sets StartTime. We should probably reset StartTime on first input.
 		// But for simplicity, let's just let it be.
 		m.Game.StartTime = time.Now()
+		m.gameID++
+		m.fetchingMore = false
+		m.Ghost = m.newGhost(m.Game.TargetText)
+		if m.Config.Mode == config.ModeTime {
+			// The countdown starts with the first keystroke rather than on load
+			m.Game.IsStarted = false
+			m.Game.TimeLimit = time.Duration(m.Config.TimeLimit) * time.Second
+			next, cmd := m.prefetch()
+			return next, tea.Batch(cmd, timerTick(m.gameID))
+		}
+		if m.Ghost != nil {
+			// Keep redrawing so the ghost moves between keystrokes
+			return m, timerTick(m.gameID)
+		}
+		return m, nil
+
+	case timerTickMsg:
+		if msg.gameID != m.gameID || m.Game == nil || m.Game.IsComplete {
+			return m, nil
+		}
+		if m.Game.CheckTime() {
+			m.saveMetrics()
+			return m, nil
+		}
+		next, cmd := m.prefetch()
+		return next, tea.Batch(cmd, timerTick(m.gameID))
+
+	case moreContentMsg:
+		if msg.gameID != m.gameID {
+			return m, nil
+		}
+		m.fetchingMore = false
+		if msg.err != nil {
+			m.fetchRetryAt = time.Now().Add(prefetchRetryDelay)
+			return m, nil
+		}
+		if !m.Game.IsComplete {
+			m.Game.AppendText(msg.content.Text)
+			m.Sources = append(m.Sources, msg.content)
+		}
 		return m, nil
 
 	case errorMsg:
+		if msg.loadID != 0 {
+			if msg.loadID != m.loadID {
+				return m, nil
+			}
+			m.cancelLoad()
+		}
 		m.Err = msg.err
 		m.IsLoading = false
 		return m, nil
 
+	case openErrMsg:
+		return m.showStatus(fmt.Sprintf("Couldn't open %s: %v", msg.url, msg.err))
+
+	case statusClearMsg:
+		if msg.id == m.statusID {
+			m.Status = ""
+		}
+		return m, nil
+
+	case raceMsg:
+		return m.updateRace(msg)
+
+	case raceClosedMsg:
+		m.Err = errors.New("lost connection to the race server")
+		return m, nil
+
+	case raceErrMsg:
+		m.RaceNotice = "Error: " + msg.err.Error()
+		return m, nil
+
+	case replayTickMsg:
+		return m.tickReplay(msg)
+
 	case spinner.TickMsg:
 		var cmd tea.Cmd
 		m.Spinner, cmd = m.Spinner.Update(msg)
@@ -234,12 +413,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) View() string {
+	if m.ShowHome {
+		return m.renderHome()
+	}
+
 	if m.Err != nil {
-		return fmt.Sprintf("Error: %v\nPress q to quit", m.Err)
+		return m.renderError()
+	}
+
+	if m.Race != nil && m.Game == nil {
+		return m.renderLobby()
 	}
 
 	if m.IsLoading {
-		return fmt.Sprintf("\n %s Loading content from %s...\n\n", m.Spinner.View(), m.Plugin.Name())
+		hint := "p: switch plugin  q: cancel and quit"
+		if m.Race != nil {
+			hint = "q: quit"
+		}
+		return fmt.Sprintf("\n %s Loading content from %s...\n\n %s\n", m.Spinner.View(), m.Plugin.Name(), UntypedStyle.Render(hint))
 	}
 
 	if m.Game == nil {
@@ -256,6 +447,9 @@ func (m Model) View() string {
 		if m.ShowTrend {
 			return m.renderTrend()
 		}
+		if m.ShowReplay {
+			return m.renderReplay()
+		}
 		return m.renderResults()
 	}
 
@@ -265,30 +459,92 @@ func (m Model) View() string {
 func (m Model) renderGame() string {
 	var s strings.Builder
 
-	s.WriteString(TitleStyle.Render("Go Racer - " + m.Plugin.Name()))
+	title := "Go Racer - " + m.Plugin.Name()
+	if m.Game.IsTimed() {
+		title += fmt.Sprintf("   %s   %.0f WPM", formatCountdown(m.Game.Remaining()), m.Game.WPM())
+	}
+	s.WriteString(TitleStyle.Render(title))
 	s.WriteString("\n\n")
+	if m.CurrentContent != nil && m.CurrentContent.Stale {
+		s.WriteString(UntypedStyle.Render("Offline: typing a cached text"))
+		s.WriteString("\n\n")
+	}
+
+	ghostPos := -1
+	if m.Ghost != nil {
+		ghostPos = m.Ghost.Position(m.Game.Elapsed())
+	}
+	s.WriteString(m.renderTypingText(m.Game, ghostPos))
 
-	// Render text with highlighting
+	if m.Ghost != nil {
+		s.WriteString("\n\n")
+		s.WriteString(m.renderGhostStatus())
+	}
+
+	if m.Race != nil {
+		s.WriteString("\n\n")
+		s.WriteString(m.renderLanes())
+	}
+
+	s.WriteString("\n\n")
+	if m.Game.Code {
+		s.WriteString(UntypedStyle.Render("Start typing... Enter for new lines, Tab to indent. Press Esc to finish, Ctrl+C to quit"))
+	} else {
+		s.WriteString(UntypedStyle.Render("Start typing... Press Esc to finish, Ctrl+C to quit"))
+	}
+
+	return s.String()
+}
+
+// renderTypingText draws the target text of a test in green/red/grey with the
+// cursor underlined, word wrapped to the window. It is shared by live tests and
+// replays. A ghost cursor is drawn at ghostPos unless it is negative.
+func (m Model) renderTypingText(g *game.TypingTest, ghostPos int) string {
+	// Render text with highlighting, one grapheme cluster at a time so that
+	// emoji sequences and combining marks are never split by escape codes
+	input := g.InputRunes()
+	cursor := len(input)
 	var textBuilder strings.Builder
-	for i, char := range m.Game.TargetText {
+	code := isCode(g)
+	forEachCluster(g.TargetText, func(start int, cluster []rune) {
 		var style lipgloss.Style
-
-		if i < len(m.Game.UserInput) {
-			if byte(char) == m.Game.UserInput[i] {
-				style = CorrectStyle
-			} else {
-				style = ErrorStyle
+		mistyped := false
+
+		if start < len(input) {
+			style = CorrectStyle
+			for j, char := range cluster {
+				if start+j >= len(input) || input[start+j] != char {
+					style = ErrorStyle
+					mistyped = true
+					break
+				}
 			}
 		} else {
 			style = UntypedStyle
 		}
 
 		// Underline current character
-		if i == len(m.Game.UserInput) {
+		if cursor >= start && cursor < start+len(cluster) {
 			style = style.Copy().Underline(true)
 		}
+		if ghostPos >= start && ghostPos < start+len(cluster) {
+			style = style.Copy().Inherit(GhostStyle)
+		}
 
-		textBuilder.WriteString(style.Render(string(char)))
+		if code && cluster[0] == '\n' {
+			// Line breaks are only drawn where the cursor is or a mistake was made
+			if cursor == start || ghostPos == start || mistyped {
+				textBuilder.WriteString(style.Render("↵"))
+			}
+			textBuilder.WriteString("\n")
+			return
+		}
+		textBuilder.WriteString(style.Render(string(cluster)))
+	})
+
+	// Code keeps its own line breaks, and wrapping would break up indentation
+	if code {
+		return textBuilder.String()
 	}
 
 	// Apply word wrap
@@ -301,17 +557,12 @@ func (m Model) renderGame() string {
 			width = 20
 		}
 	}
-	s.WriteString(wordwrap.String(textBuilder.String(), width))
-
-	s.WriteString("\n\n")
-	s.WriteString(UntypedStyle.Render("Start typing... Press Esc to finish, Ctrl+C to quit"))
-
-	return s.String()
+	return wordwrap.String(textBuilder.String(), width)
 }
 
 func (m Model) renderResults() string {
 	duration := m.Game.EndTime.Sub(m.Game.StartTime)
-	wpm := (float64(len(m.Game.UserInput)) / 5.0) / duration.Minutes()
+	wpm := m.Game.WPM()
 	accuracy := m.Game.Accuracy()
 
 	var s strings.Builder
@@ -320,19 +571,23 @@ func (m Model) renderResults() string {
 
 	// Render the text with historical accuracy colors
 	var textBuilder strings.Builder
-	for i, char := range m.Game.TargetText {
-		var style lipgloss.Style
-		if mistyped, attempted := m.Game.InitialMistake[i]; attempted {
-			if mistyped {
-				style = ErrorStyle
-			} else {
+	forEachCluster(m.Game.TargetText, func(start int, cluster []rune) {
+		style := UntypedStyle
+		for j := range cluster {
+			if mistyped, attempted := m.Game.InitialMistake[start+j]; attempted {
+				if mistyped {
+					style = ErrorStyle
+					break
+				}
 				style = CorrectStyle
 			}
-		} else {
-			style = UntypedStyle
 		}
-		textBuilder.WriteString(style.Render(string(char)))
-	}
+		if cluster[0] == '\n' {
+			textBuilder.WriteString("\n")
+			return
+		}
+		textBuilder.WriteString(style.Render(string(cluster)))
+	})
 
 	// Apply word wrap
 	width := m.width - 8 // Account for border (2) + padding (4) + extra safety (2)
@@ -343,27 +598,75 @@ func (m Model) renderResults() string {
 			width = 20
 		}
 	}
-	s.WriteString(wordwrap.String(textBuilder.String(), width))
+	if isCode(m.Game) {
+		s.WriteString(textBuilder.String())
+	} else {
+		s.WriteString(wordwrap.String(textBuilder.String(), width))
+	}
+	if m.CurrentContent != nil && m.CurrentContent.Author != "" && len(m.Sources) <= 1 {
+		s.WriteString("\n" + UntypedStyle.Render("— "+m.CurrentContent.Author))
+	}
 	s.WriteString("\n\n")
 
+	if m.Race != nil {
+		s.WriteString(m.renderPlacements())
+		s.WriteString("\n")
+	}
+
+	if m.Ghost != nil {
+		s.WriteString(m.renderGhostGap())
+		s.WriteString("\n\n")
+	}
+
+	retry := "Press 'r' to retry, 'q' to quit\n"
+	switchPlugin := fmt.Sprintf("Press 'p' to switch plugin (Current: %s)\n", m.Plugin.Name())
+	if m.Race != nil {
+		retry = "Waiting for the host to start the next race, 'q' to quit\n"
+		if m.RaceServer != nil {
+			retry = "Press 'r' to start the next race, 'q' to quit\n"
+		}
+		switchPlugin = ""
+		if m.RaceNotice != "" {
+			retry = m.RaceNotice + "\n" + retry
+		}
+	}
+
 	content := fmt.Sprintf(
 		"WPM:      %.2f\n"+
 			"Accuracy: %.2f%%\n"+
-			"Time:     %.2fs\n\n"+
-			"Press 'r' to retry, 'q' to quit\n"+
-			"Press 'm' to view metrics\n"+
-			"Press ',' for settings\n"+
-			"Press 'p' to switch plugin (Current: %s)\n"+
-			"Press 't' to view trend",
-		wpm, accuracy, duration.Seconds(), m.Plugin.Name(),
-	)
-
-	if m.CurrentContent != nil && m.CurrentContent.SourceURL != "" {
+			"Time:     %.2fs\n\n",
+		wpm, accuracy, duration.Seconds(),
+	) +
+		retry +
+		"Press 'm' to view metrics\n" +
+		"Press ',' for settings\n" +
+		switchPlugin +
+		"Press 't' to view trend\n" +
+		"Press 'v' to replay this run"
+
+	if len(m.Sources) > 1 {
+		content += "\n\nSources:"
+		for i, src := range m.Sources {
+			if i == 9 {
+				content += fmt.Sprintf("\n   ...and %d more", len(m.Sources)-i)
+				break
+			}
+			content += fmt.Sprintf("\n%d. %s", i+1, truncate(src.Text, 40))
+			if src.SourceURL != "" {
+				content += "  " + UntypedStyle.Render(src.SourceURL)
+			}
+		}
+		content += "\nPress '1'-'9' to open a source"
+	} else if m.CurrentContent != nil && m.CurrentContent.SourceURL != "" {
 		content += "\nPress 'Enter' to open source"
 	}
 
 	s.WriteString(content)
 
+	if m.Status != "" {
+		s.WriteString("\n\n" + ErrorStyle.Render(m.Status))
+	}
+
 	return ResultsStyle.Render(s.String())
 }
 
@@ -385,53 +688,247 @@ func (m Model) renderSettings() string {
 	s.WriteString(checkbox("Include Capital Letters", m.Config.IncludeCapitalLetters, "c"))
 	s.WriteString(checkbox("Include Non-Standard", m.Config.IncludeNonStandardChars, "s"))
 
+	mode := "Text"
+	switch m.Config.Mode {
+	case config.ModeTime:
+		mode = "Time"
+	case config.ModeWords:
+		mode = "Words"
+	}
+	length := "-"
+	if label := m.modeLabel(); label != "" {
+		length = label
+	}
+	s.WriteString(fmt.Sprintf("\n%-29s (m)\n", "Mode: "+mode))
+	s.WriteString(fmt.Sprintf("%-29s (l)\n", "Length: "+length))
+
+	ghost := "Off"
+	switch m.Config.Ghost {
+	case config.GhostBest:
+		ghost = "Personal Best"
+	case config.GhostPace:
+		ghost = "Target Pace"
+	}
+	s.WriteString(fmt.Sprintf("\n%-29s (g)\n", "Ghost: "+ghost))
+	s.WriteString(fmt.Sprintf("%-29s (w)\n", fmt.Sprintf("Ghost Pace: %d WPM", m.Config.GhostWPM)))
+
+	s.WriteString(fmt.Sprintf("\n%-29s (t)\n", fmt.Sprintf("Code Tab Width: %d", tabWidth(m.Config))))
+	s.WriteString(checkbox("Code Auto-Indent", m.Config.AutoIndent, "i"))
+	s.WriteString(m.renderPluginSettings())
+
 	s.WriteString("\nPress ',' or 'Esc' to return\n")
 
 	return ResultsStyle.Render(s.String())
 }
 
+// forEachCluster calls fn for every grapheme cluster in text, passing the
+// rune index the cluster starts at
+func forEachCluster(text string, fn func(start int, cluster []rune)) {
+	start := 0
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		cluster := g.Runes()
+		fn(start, cluster)
+		start += len(cluster)
+	}
+}
+
+// modeKey is the mode runs are recorded under. Races always type the host's
+// whole text, so they count as plain text tests whatever the mode setting.
+func (m Model) modeKey() string {
+	if m.Race != nil {
+		return ""
+	}
+	return m.Config.ModeKey()
+}
+
+// modeLabel describes the length of the current test mode, e.g. "30s" or "25 words"
+func (m Model) modeLabel() string {
+	switch m.Config.Mode {
+	case config.ModeTime:
+		return fmt.Sprintf("%ds", m.Config.TimeLimit)
+	case config.ModeWords:
+		return fmt.Sprintf("%d words", m.Config.WordCount)
+	default:
+		return ""
+	}
+}
+
+// truncate shortens s to at most n runes, adding an ellipsis when cut
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
 // Messages
 type contentMsg struct {
 	content *plugins.Content
+	sources []*plugins.Content
+	loadID  int
 }
 
 type errorMsg struct {
-	err error
+	err    error
+	loadID int // Set when the error comes from loading content
+}
+
+type timerTickMsg struct {
+	gameID int
 }
 
+type moreContentMsg struct {
+	gameID  int
+	content *plugins.Content
+	err     error
+}
+
+const (
+	timerTickInterval  = 100 * time.Millisecond
+	prefetchThreshold  = 80 // Runes left in the buffer before more content is fetched
+	prefetchRetryDelay = 2 * time.Second
+)
+
 // Commands
-func (m Model) loadContent() tea.Msg {
-	content, err := m.Plugin.GetContent()
+func (m Model) loadContent(ctx context.Context) tea.Msg {
+	if m.Config.Mode == config.ModeWords {
+		return m.loadWords(ctx, m.Config.WordCount)
+	}
+
+	content, err := m.Plugin.GetContent(ctx)
 	if err != nil {
-		return errorMsg{err}
+		return errorMsg{err: err}
 	}
-	content.Text = game.ApplyFilters(content.Text, m.Config)
-	return contentMsg{content}
+	PrepareContent(content, m.Config)
+	return contentMsg{content: content, sources: []*plugins.Content{content}}
 }
 
-func (m *Model) saveMetrics() {
-	if m.Config.Metrics == nil {
-		m.Config.Metrics = make(map[string]config.CharMetric)
+// loadWords fetches items until there are at least n words of text, then
+// stitches them together and trims the result to exactly n words
+func (m Model) loadWords(ctx context.Context, n int) tea.Msg {
+	var sources []*plugins.Content
+	var texts []string
+	words := 0
+
+	// Guard against sources that keep returning empty text
+	for attempts := 0; words < n && attempts < n+10; attempts++ {
+		content, err := m.Plugin.GetContent(ctx)
+		if err != nil {
+			return errorMsg{err: err}
+		}
+		PrepareContent(content, m.Config)
+		if content.Text == "" {
+			continue
+		}
+		sources = append(sources, content)
+		texts = append(texts, content.Text)
+		words += game.CountWords(content.Text)
 	}
 
-	sessionStats := m.Game.GetSessionStats()
-	for char, stat := range sessionStats {
-		existing := m.Config.Metrics[char]
-		existing.Attempts += stat.Attempts
-		existing.Mistakes += stat.Mistakes
-		existing.Mistakes += stat.Mistakes
-		m.Config.Metrics[char] = existing
+	if words < n {
+		return errorMsg{err: fmt.Errorf("%s only provided %d of %d words", m.Plugin.Name(), words, n)}
+	}
+
+	stitched := &plugins.Content{
+		Text:      game.TrimWords(strings.Join(texts, " "), n),
+		SourceURL: sources[0].SourceURL,
+		Author:    sources[0].Author,
+		Code:      sources[0].Code,
+	}
+	if stitched.Code {
+		stitched.Text = game.CutWords(strings.Join(texts, "\n"), n)
+	}
+	for _, src := range sources {
+		stitched.Stale = stitched.Stale || src.Stale
+	}
+	return contentMsg{content: stitched, sources: sources}
+}
+
+func timerTick(gameID int) tea.Cmd {
+	return tea.Tick(timerTickInterval, func(time.Time) tea.Msg {
+		return timerTickMsg{gameID}
+	})
+}
+
+// prefetch starts a background fetch when a timed test is running low on text
+func (m Model) prefetch() (tea.Model, tea.Cmd) {
+	if !m.Game.IsTimed() || m.fetchingMore || time.Now().Before(m.fetchRetryAt) {
+		return m, nil
+	}
+	if m.Game.Length()-m.Game.Position() > prefetchThreshold {
+		return m, nil
+	}
+
+	m.fetchingMore = true
+	gameID := m.gameID
+	plugin := m.Plugin
+	cfg := m.Config
+	timeout := m.fetchTimeout()
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		content, err := plugin.GetContent(ctx)
+		if err != nil {
+			return moreContentMsg{gameID: gameID, err: err}
+		}
+		PrepareContent(content, cfg)
+		return moreContentMsg{gameID: gameID, content: content}
+	}
+}
+
+// nextOption returns the option after current, wrapping around
+func nextOption(options []int, current int) int {
+	for i, option := range options {
+		if option == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
+}
+
+// formatCountdown formats the time left in a timed test as m:ss
+func formatCountdown(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+func (m *Model) saveMetrics() {
+	charStats := make(map[string]config.CharMetric)
+	for char, stat := range m.Game.GetSessionStats() {
+		charStats[char] = config.CharMetric{Attempts: stat.Attempts, Mistakes: stat.Mistakes}
 	}
 
 	// Save history
 	result := config.GameResult{
-		WPM:       m.Game.WPM(),
-		Accuracy:  m.Game.Accuracy(),
-		Timestamp: time.Now().Unix(),
+		WPM:        m.Game.WPM(),
+		Accuracy:   m.Game.Accuracy(),
+		Timestamp:  time.Now().Unix(),
+		Plugin:     m.CurrentPluginName,
+		Mode:       m.modeKey(),
+		Text:       m.Game.TargetText,
+		Keystrokes: m.Game.Keystrokes,
+		CharStats:  charStats,
 	}
-	m.Config.History = append(m.Config.History, result)
 
-	_ = config.Save(m.Config)
+	// The store also picks up runs from other instances, so reload from it
+	if m.Store != nil && m.Store.Append(result) == nil {
+		m.History = m.Store.History()
+		m.Metrics = m.Store.Metrics()
+		return
+	}
+
+	m.History = append(m.History, result)
+	if m.Metrics == nil {
+		m.Metrics = make(map[string]config.CharMetric)
+	}
+	for char, stat := range charStats {
+		existing := m.Metrics[char]
+		existing.Attempts += stat.Attempts
+		existing.Mistakes += stat.Mistakes
+		m.Metrics[char] = existing
+	}
 }
 
 func (m Model) renderMetrics() string {
@@ -449,7 +946,7 @@ func (m Model) renderMetrics() string {
 	// Map to aggregate stats by lowercase character
 	aggregatedStats := make(map[string]struct{ Attempts, Mistakes int })
 
-	for char, metric := range m.Config.Metrics {
+	for char, metric := range m.Metrics {
 		runes := []rune(char)
 		if len(runes) != 1 {
 			continue
@@ -503,8 +1000,11 @@ func (m Model) renderMetrics() string {
 			break
 		}
 		displayChar := stat.Char
-		if displayChar == " " {
+		switch displayChar {
+		case " ":
 			displayChar = "SPC"
+		case "\n":
+			displayChar = "ENT"
 		}
 		s.WriteString(fmt.Sprintf("%-5s | %-9.1f%% | %-10d | %d\n", displayChar, stat.Accuracy, stat.Mistakes, stat.Attempts))
 		count++
@@ -517,10 +1017,20 @@ func (m Model) renderMetrics() string {
 
 func (m Model) renderTrend() string {
 	var s strings.Builder
-	s.WriteString(ResultsStyle.Render("WPM Trend (Last 20 Games)"))
+	title := "WPM Trend (Last 20 Games)"
+	if label := m.modeLabel(); label != "" && m.Race == nil {
+		title = fmt.Sprintf("WPM Trend (Last 20 Games, %s)", label)
+	}
+	s.WriteString(ResultsStyle.Render(title))
 	s.WriteString("\n\n")
 
-	history := m.Config.History
+	// Only runs from the current mode are comparable
+	var history []config.GameResult
+	for _, res := range m.History {
+		if res.Mode == m.modeKey() {
+			history = append(history, res)
+		}
+	}
 	if len(history) == 0 {
 		s.WriteString("No games played yet.")
 		return ResultsStyle.Render(s.String())
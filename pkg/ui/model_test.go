@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+	"go-racer/pkg/plugins"
+)
+
+func TestResults_ShowAuthor(t *testing.T) {
+	m := InitialModel(&stubSource{items: []string{"go"}}, "stub", config.Default(), nil)
+	m.IsLoading = false
+	m.Game = game.NewTypingTest("Simplicity is prerequisite for reliability.")
+	m.CurrentContent = &plugins.Content{Text: m.Game.TargetText, Author: "Edsger W. Dijkstra"}
+	m.Game.Start()
+	m.Game.Complete()
+
+	if view := m.View(); !strings.Contains(view, "— Edsger W. Dijkstra") {
+		t.Errorf("results should name the author:\n%s", view)
+	}
+
+	m.CurrentContent.Author = ""
+	if view := m.View(); strings.Contains(view, "—") {
+		t.Errorf("results should leave out a missing author:\n%s", view)
+	}
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const statusDuration = 5 * time.Second // How long a status line stays up
+
+// openErrMsg reports a link that couldn't be opened. Unlike errorMsg it
+// leaves the results up and only shows a status line for a moment.
+type openErrMsg struct {
+	url string
+	err error
+}
+
+// statusClearMsg hides the status line it was sent for, unless a newer one
+// has replaced it
+type statusClearMsg struct {
+	id int
+}
+
+func openURL(url string) tea.Cmd {
+	return tea.ExecProcess(openCommand(url, os.Getenv("EDITOR")), func(err error) tea.Msg {
+		if err != nil {
+			return openErrMsg{url: url, err: err}
+		}
+		return nil
+	})
+}
+
+// showStatus sets the status line and clears it after statusDuration
+func (m Model) showStatus(status string) (tea.Model, tea.Cmd) {
+	m.statusID++
+	m.Status = status
+	id := m.statusID
+	return m, tea.Tick(statusDuration, func(time.Time) tea.Msg {
+		return statusClearMsg{id}
+	})
+}
+
+// openCommand opens local files in the user's editor at the linked line
+// (file:///path#L12 runs "$EDITOR +12 /path") and anything else with the
+// system's opener
+func openCommand(link, editor string) *exec.Cmd {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "file" {
+		return exec.Command("open", link)
+	}
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return exec.Command("open", u.Path)
+	}
+	if line, err := strconv.Atoi(strings.TrimPrefix(u.Fragment, "L")); err == nil {
+		args = append(args, "+"+strconv.Itoa(line))
+	}
+	return exec.Command(args[0], append(args[1:], u.Path)...)
+}
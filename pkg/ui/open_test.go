@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+)
+
+func TestOpenCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		link   string
+		editor string
+		want   []string
+	}{
+		{"Web", "https://example.com/a", "vim", []string{"open", "https://example.com/a"}},
+		{"FileInEditor", "file:///src/my%20app/main.go#L12", "vim", []string{"vim", "+12", "/src/my app/main.go"}},
+		{"EditorWithArgs", "file:///src/main.go#L3", "emacs -nw", []string{"emacs", "-nw", "+3", "/src/main.go"}},
+		{"FileWithoutLine", "file:///src/main.go", "nano", []string{"nano", "/src/main.go"}},
+		{"FileWithoutEditor", "file:///src/main.go#L3", "", []string{"open", "/src/main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openCommand(tt.link, tt.editor).Args; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("openCommand() args = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenError_ShowsStatus(t *testing.T) {
+	m := InitialModel(&stubSource{items: []string{"go"}}, "stub", config.Default(), nil)
+	m.Game = game.NewTypingTest("go")
+	m.IsLoading = false
+	m.Game.Start()
+	m.Game.Complete()
+
+	next, cmd := m.Update(openErrMsg{url: "https://example.com", err: errors.New("exec: no opener")})
+	m = next.(Model)
+	if m.Err != nil {
+		t.Errorf("Err = %v, want the results left up", m.Err)
+	}
+	if cmd == nil {
+		t.Error("expected a timer to clear the status")
+	}
+	if view := m.View(); !strings.Contains(view, "Couldn't open https://example.com") || strings.Contains(view, "Couldn't load") {
+		t.Errorf("view should show the status on the results screen:\n%s", view)
+	}
+
+	// Only the timer of the latest status clears it
+	next, _ = m.Update(statusClearMsg{id: m.statusID - 1})
+	if next.(Model).Status == "" {
+		t.Error("an older timer cleared the status")
+	}
+	next, _ = m.Update(statusClearMsg{id: m.statusID})
+	if status := next.(Model).Status; status != "" {
+		t.Errorf("Status = %q, want it cleared", status)
+	}
+}
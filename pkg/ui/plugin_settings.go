@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/plugins"
+)
+
+// pluginSettingsState tracks the generated plugin section of the settings screen
+type pluginSettingsState struct {
+	Cursor  int  // Selected setting
+	Editing bool // A text value is being typed into Input
+	Input   textinput.Model
+	Err     string
+}
+
+// configurable returns the current plugin's settings, or nil if it has none.
+// Race texts are chosen by the host, so plugins are not configured mid-race.
+func (m Model) configurable() plugins.Configurable {
+	if m.Race != nil {
+		return nil
+	}
+	c, _ := m.Plugin.(plugins.Configurable)
+	return c
+}
+
+func (m Model) pluginValues(c plugins.Configurable) map[string]string {
+	return plugins.Values(c, m.Config.PluginOptions[m.CurrentPluginName])
+}
+
+// updatePluginSettings handles keys for the plugin section of the settings
+// screen. It reports whether the key was consumed.
+func (m Model) updatePluginSettings(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	c := m.configurable()
+	if c == nil {
+		return m, nil, false
+	}
+	settings := c.Settings()
+	if len(settings) == 0 {
+		return m, nil, false
+	}
+	ps := &m.PluginSettings
+	if ps.Cursor >= len(settings) {
+		ps.Cursor = 0
+	}
+	setting := settings[ps.Cursor]
+
+	if ps.Editing {
+		switch msg.Type {
+		case tea.KeyEsc:
+			ps.Editing = false
+			ps.Err = ""
+		case tea.KeyEnter:
+			m.setPluginOption(c, setting, strings.TrimSpace(ps.Input.Value()))
+			if ps.Err == "" {
+				ps.Editing = false
+			}
+		default:
+			var cmd tea.Cmd
+			ps.Input, cmd = ps.Input.Update(msg)
+			return m, cmd, true
+		}
+		return m, nil, true
+	}
+
+	switch msg.Type {
+	case tea.KeyUp:
+		ps.Cursor = (ps.Cursor + len(settings) - 1) % len(settings)
+		ps.Err = ""
+	case tea.KeyDown:
+		ps.Cursor = (ps.Cursor + 1) % len(settings)
+		ps.Err = ""
+	case tea.KeyEnter:
+		value := m.pluginValues(c)[setting.Key]
+		switch setting.Type {
+		case plugins.SettingBool:
+			on, _ := strconv.ParseBool(value)
+			m.setPluginOption(c, setting, strconv.FormatBool(!on))
+		case plugins.SettingEnum:
+			m.setPluginOption(c, setting, nextEnum(setting.Options, value))
+		default:
+			ps.Input = textinput.New()
+			ps.Input.Cursor.SetMode(cursor.CursorStatic) // Blink messages are not routed here
+			ps.Input.SetValue(value)
+			ps.Input.CursorEnd()
+			ps.Input.Focus()
+			ps.Editing = true
+			ps.Err = ""
+		}
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// setPluginOption validates and stores one setting, then reconfigures the
+// plugin so the change applies to the next text
+func (m *Model) setPluginOption(c plugins.Configurable, setting plugins.Setting, value string) {
+	if err := setting.Validate(value); err != nil {
+		m.PluginSettings.Err = err.Error()
+		return
+	}
+
+	values := m.pluginValues(c)
+	values[setting.Key] = value
+	if err := c.Configure(values); err != nil {
+		m.PluginSettings.Err = err.Error()
+		// Restore the previous values so the plugin keeps working
+		_ = plugins.Configure(m.Plugin, m.Config.PluginOptions[m.CurrentPluginName])
+		return
+	}
+
+	m.PluginSettings.Err = ""
+	m.Config.SetPluginOption(m.CurrentPluginName, setting.Key, value)
+	_ = config.Save(m.Config)
+}
+
+// nextEnum cycles to the option after current
+func nextEnum(options []string, current string) string {
+	if len(options) == 0 {
+		return current
+	}
+	for i, option := range options {
+		if option == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
+}
+
+func (m Model) renderPluginSettings() string {
+	c := m.configurable()
+	if c == nil || len(c.Settings()) == 0 {
+		return ""
+	}
+
+	var s strings.Builder
+	s.WriteString(fmt.Sprintf("\n%s Settings (↑/↓ select, Enter change)\n", m.Plugin.Name()))
+
+	values := m.pluginValues(c)
+	for i, setting := range c.Settings() {
+		marker := "  "
+		if i == m.PluginSettings.Cursor {
+			marker = "> "
+		}
+
+		value := values[setting.Key]
+		if setting.Type == plugins.SettingBool {
+			if on, _ := strconv.ParseBool(value); on {
+				value = "[x]"
+			} else {
+				value = "[ ]"
+			}
+		}
+		if i == m.PluginSettings.Cursor && m.PluginSettings.Editing {
+			value = m.PluginSettings.Input.View()
+		} else {
+			value = truncate(value, 40)
+		}
+		s.WriteString(fmt.Sprintf("%s%-14s %s\n", marker, setting.Label+":", value))
+
+		if i == m.PluginSettings.Cursor {
+			s.WriteString(UntypedStyle.Render("  "+setting.Description) + "\n")
+		}
+	}
+
+	if m.PluginSettings.Err != "" {
+		s.WriteString(ErrorStyle.Render(m.PluginSettings.Err) + "\n")
+	}
+	return s.String()
+}
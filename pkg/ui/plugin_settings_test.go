@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+	"go-racer/pkg/plugins"
+)
+
+func settingsModel(t *testing.T) (Model, *plugins.HackerNewsSource) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	src := plugins.NewHackerNewsSource(plugins.HTTPOptions{})
+	g := game.NewTypingTest("done")
+	g.IsComplete = true
+	return Model{
+		Plugin:            src,
+		CurrentPluginName: "hn",
+		Config:            config.Default(),
+		Game:              g,
+		ShowSettings:      true,
+	}, src
+}
+
+func press(t *testing.T, m Model, keys ...tea.KeyMsg) Model {
+	t.Helper()
+	for _, key := range keys {
+		next, _ := m.Update(key)
+		m = next.(Model)
+	}
+	return m
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestPluginSettings_EditValue(t *testing.T) {
+	m, src := settingsModel(t)
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.PluginSettings.Editing {
+		t.Fatal("expected Enter to start editing the selected setting")
+	}
+
+	// Letters go to the input rather than toggling other settings
+	m = press(t, m, backspace, backspace, runes("n"), backspace, runes("10"), tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.PluginSettings.Editing {
+		t.Errorf("still editing, error %q", m.PluginSettings.Err)
+	}
+	if !m.Config.IncludeNumbers {
+		t.Error("typing into the input toggled Include Numbers")
+	}
+	if src.Stories != 10 {
+		t.Errorf("Stories = %d, want 10", src.Stories)
+	}
+	if got := m.Config.PluginOptions["hn"]["stories"]; got != "10" {
+		t.Errorf("stored stories = %q, want %q", got, "10")
+	}
+}
+
+func TestPluginSettings_RejectsInvalid(t *testing.T) {
+	m, src := settingsModel(t)
+
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEnter}, runes("x"), tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.PluginSettings.Editing || m.PluginSettings.Err == "" {
+		t.Fatalf("expected an error while still editing, got editing=%v err=%q", m.PluginSettings.Editing, m.PluginSettings.Err)
+	}
+
+	// Esc cancels the edit without leaving the settings screen
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.PluginSettings.Editing || !m.ShowSettings {
+		t.Errorf("editing=%v showSettings=%v, want false and true", m.PluginSettings.Editing, m.ShowSettings)
+	}
+	if src.Stories != 50 {
+		t.Errorf("Stories = %d, want unchanged 50", src.Stories)
+	}
+	if _, ok := m.Config.PluginOptions["hn"]; ok {
+		t.Error("invalid value was stored")
+	}
+}
+
+func TestNextEnum(t *testing.T) {
+	options := []string{"top", "new", "best"}
+	tests := []struct {
+		current, want string
+	}{
+		{"top", "new"},
+		{"best", "top"},
+		{"unknown", "top"},
+	}
+	for _, tt := range tests {
+		if got := nextEnum(options, tt.current); got != tt.want {
+			t.Errorf("nextEnum(%q) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+}
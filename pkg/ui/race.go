@@ -0,0 +1,257 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-racer/pkg/config"
+	"go-racer/pkg/game"
+	"go-racer/pkg/plugins"
+	"go-racer/pkg/race"
+	"go-racer/pkg/store"
+)
+
+const laneWidth = 30
+
+// remoteSource stands in for the plugin of a race joined over the network,
+// where the host picks every text
+type remoteSource struct {
+	name string
+}
+
+func (r remoteSource) Name() string        { return r.name }
+func (r remoteSource) Description() string { return "Text chosen by the race host" }
+func (r remoteSource) GetContent(context.Context) (*plugins.Content, error) {
+	return nil, errors.New("content is chosen by the race host")
+}
+
+// InitialRaceModel creates a model taking part in a LAN race. The host passes
+// its server so it can start races; joiners pass nil.
+func InitialRaceModel(client *race.Client, server *race.Server, addrs []string, cfg *config.Config, st *store.Store) Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	var plugin plugins.ContentSource = remoteSource{name: "Race"}
+	if server != nil {
+		plugin = server.Source
+	}
+
+	m := Model{
+		Plugin:            plugin,
+		CurrentPluginName: "race",
+		Spinner:           s,
+		Config:            cfg,
+		Race:              client,
+		RaceServer:        server,
+		RaceAddrs:         addrs,
+	}
+	m.attachStore(st)
+	return m
+}
+
+type raceMsg race.Message
+
+type raceClosedMsg struct{}
+
+type raceErrMsg struct {
+	err error
+}
+
+func waitForRace(c *race.Client) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-c.Messages
+		if !ok {
+			return raceClosedMsg{}
+		}
+		return raceMsg(msg)
+	}
+}
+
+// startRace asks the host's server to pick a text and start everyone racing
+func (m Model) startRace() tea.Cmd {
+	srv := m.RaceServer
+	timeout := m.fetchTimeout()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := srv.Start(ctx); err != nil {
+			return raceErrMsg{err}
+		}
+		return nil
+	}
+}
+
+// reportProgress sends the local racer's progress to the server
+func (m Model) reportProgress() tea.Cmd {
+	if m.Race == nil || m.Game == nil {
+		return nil
+	}
+	client := m.Race
+	progress, wpm, done := m.Game.Position(), m.Game.WPM(), m.Game.IsComplete
+	return func() tea.Msg {
+		if err := client.SendProgress(progress, wpm, done); err != nil {
+			return raceErrMsg{err}
+		}
+		return nil
+	}
+}
+
+func (m Model) updateRace(msg raceMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case race.MsgState:
+		m.Racers = msg.Racers
+		m.RaceLength = msg.Length
+
+	case race.MsgStart:
+		if m.RaceServer == nil {
+			m.Plugin = remoteSource{name: msg.Plugin}
+		}
+		content := &plugins.Content{Text: msg.Text, SourceURL: msg.SourceURL, Code: msg.Code}
+		m.Game = game.NewTypingTest(msg.Text)
+		m.Game.Code = msg.Code
+		m.Game.AutoIndent = msg.Code && m.Config.AutoIndent
+		m.Game.Start()
+		m.CurrentContent = content
+		m.Sources = []*plugins.Content{content}
+		m.RaceLength = msg.Length
+		m.RaceOver = false
+		m.RaceNotice = ""
+		m.Ghost = nil
+		m.ShowSettings = false
+		m.ShowMetrics = false
+		m.ShowTrend = false
+		m.ShowReplay = false
+		m.gameID++
+
+	case race.MsgResults:
+		m.Racers = msg.Racers
+		m.RaceOver = true
+	}
+	return m, waitForRace(m.Race)
+}
+
+func (m Model) updateLobby(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.String() == "q" || msg.Type == tea.KeyEsc:
+		return m.quit()
+	case msg.Type == tea.KeyEnter && m.RaceServer != nil:
+		m.RaceNotice = "Starting race..."
+		return m, m.startRace()
+	}
+	return m, nil
+}
+
+func (m Model) renderLobby() string {
+	var s strings.Builder
+	s.WriteString(TitleStyle.Render("Go Racer - Race Lobby"))
+	s.WriteString("\n\n")
+
+	if m.RaceServer != nil && len(m.RaceAddrs) > 0 {
+		s.WriteString("Others can join with:\n")
+		for _, addr := range m.RaceAddrs {
+			s.WriteString("  go-racer join " + addr + "\n")
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString(fmt.Sprintf("Racers (%d):\n", len(m.Racers)))
+	for _, r := range m.Racers {
+		name := r.Name
+		if r.ID == m.Race.ID {
+			name += " (you)"
+		}
+		s.WriteString("  " + name + "\n")
+	}
+	s.WriteString("\n")
+
+	if m.RaceNotice != "" {
+		s.WriteString(m.RaceNotice + "\n\n")
+	}
+
+	if m.RaceServer != nil {
+		s.WriteString(UntypedStyle.Render("Press Enter to start the race, q to quit"))
+	} else {
+		s.WriteString(UntypedStyle.Render("Waiting for the host to start the race... Press q to quit"))
+	}
+	return s.String()
+}
+
+// renderLanes draws one progress lane per racer
+func (m Model) renderLanes() string {
+	var s strings.Builder
+	for _, r := range m.Racers {
+		if !r.Racing {
+			continue
+		}
+
+		// Our own lane uses local state, which is ahead of the last broadcast
+		style := UntypedStyle
+		if r.ID == m.Race.ID {
+			style = CorrectStyle
+			if m.Game != nil {
+				r.Progress = m.Game.Position()
+				r.WPM = m.Game.WPM()
+			}
+		}
+
+		frac := 0.0
+		if m.RaceLength > 0 {
+			frac = float64(r.Progress) / float64(m.RaceLength)
+		}
+		if frac > 1 {
+			frac = 1
+		}
+		filled := int(frac * laneWidth)
+		bar := strings.Repeat("━", filled) + "▶" + strings.Repeat("─", laneWidth-filled)
+
+		line := fmt.Sprintf("%-12s %s %3.0f%% %5.1f WPM", truncate(r.Name, 12), bar, frac*100, r.WPM)
+		if r.Place > 0 {
+			line += fmt.Sprintf("  #%d", r.Place)
+		} else if r.Finished {
+			line += "  DNF"
+		}
+		s.WriteString(style.Render(line))
+		s.WriteString("\n")
+	}
+	return s.String()
+}
+
+// renderPlacements draws the placement board shown on the results screen
+func (m Model) renderPlacements() string {
+	var s strings.Builder
+	if m.RaceOver {
+		s.WriteString("Final Placement\n")
+	} else {
+		s.WriteString("Placement (waiting for other racers)\n")
+	}
+
+	for _, r := range m.Racers {
+		if !r.Racing {
+			continue
+		}
+		name := r.Name
+		if r.ID == m.Race.ID {
+			name += " (you)"
+		}
+		pct := 0.0
+		if m.RaceLength > 0 {
+			pct = float64(r.Progress) / float64(m.RaceLength) * 100
+		}
+		switch {
+		case r.Place > 0:
+			s.WriteString(fmt.Sprintf("%2d. %-16s %6.1f WPM\n", r.Place, truncate(name, 16), r.WPM))
+		case r.Finished:
+			s.WriteString(fmt.Sprintf("DNF %-16s gave up (%.0f%%)\n", truncate(name, 16), pct))
+		default:
+			s.WriteString(fmt.Sprintf(" -  %-16s racing (%.0f%%)\n", truncate(name, 16), pct))
+		}
+	}
+	return s.String()
+}
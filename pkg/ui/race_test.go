@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"go-racer/pkg/config"
+	"go-racer/pkg/race"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRaceModel(t *testing.T) {
+	m := InitialRaceModel(&race.Client{ID: 1}, nil, nil, &config.Config{}, nil)
+
+	if !strings.Contains(m.View(), "Waiting for the host") {
+		t.Error("joiners should see the lobby until the race starts")
+	}
+
+	next, _ := m.Update(raceMsg{Type: race.MsgStart, Plugin: "Hacker News", Text: "go fast", Length: 7})
+	m = next.(Model)
+	if m.Game == nil || m.Game.TargetText != "go fast" {
+		t.Fatal("start message should begin a test with the host's text")
+	}
+	if m.Plugin.Name() != "Hacker News" {
+		t.Errorf("Plugin.Name() = %q, want the host's plugin", m.Plugin.Name())
+	}
+
+	next, _ = m.Update(raceMsg{Type: race.MsgState, Length: 7, Racers: []race.Racer{
+		{ID: 2, Name: "bob", Racing: true, Progress: 7, WPM: 80, Finished: true, Place: 1},
+		{ID: 1, Name: "alice", Racing: true},
+		{ID: 3, Name: "carol"},
+	}})
+	m = next.(Model)
+
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("go")})
+	m = next.(Model)
+
+	view := m.renderGame()
+	if !strings.Contains(view, "bob") || !strings.Contains(view, "alice") {
+		t.Error("every racer should get a lane")
+	}
+	if strings.Contains(view, "carol") {
+		t.Error("spectators should not get a lane")
+	}
+	if !strings.Contains(view, " 29%") {
+		t.Error("our own lane should reflect local progress (2 of 7 runes)")
+	}
+
+	next, _ = m.Update(raceMsg{Type: race.MsgResults, Length: 7, Racers: []race.Racer{
+		{ID: 2, Name: "bob", Racing: true, Progress: 7, WPM: 80, Finished: true, Place: 1},
+		{ID: 1, Name: "alice", Racing: true, Progress: 7, WPM: 60, Finished: true, Place: 2},
+	}})
+	m = next.(Model)
+	m.Game.Complete()
+
+	results := m.renderPlacements()
+	if !strings.Contains(results, "Final Placement") || !strings.Contains(results, " 2. alice (you)") {
+		t.Errorf("unexpected placement board:\n%s", results)
+	}
+}
+
+func TestRaceModel_RecordsUntimed(t *testing.T) {
+	cfg := &config.Config{Mode: config.ModeTime, TimeLimit: 30}
+	m := InitialRaceModel(&race.Client{ID: 1}, nil, nil, cfg, nil)
+
+	next, _ := m.Update(raceMsg{Type: race.MsgStart, Plugin: "Hacker News", Text: "go fast", Length: 7})
+	m = next.(Model)
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("go fast")})
+	m = next.(Model)
+
+	if len(m.History) != 1 {
+		t.Fatalf("got %d runs in the history, want the race", len(m.History))
+	}
+	if mode := m.History[0].Mode; mode != "" {
+		t.Errorf("Mode = %q, want races kept out of the timed results", mode)
+	}
+}
+
+func TestRaceModel_Code(t *testing.T) {
+	cfg := &config.Config{AutoIndent: true}
+	m := InitialRaceModel(&race.Client{ID: 1}, nil, nil, cfg, nil)
+
+	next, _ := m.Update(raceMsg{Type: race.MsgStart, Plugin: "GitHub", Text: "if x {\n    y()\n}", Length: 16, Code: true})
+	m = next.(Model)
+	if !m.Game.Code || !m.Game.AutoIndent || !m.CurrentContent.Code {
+		t.Errorf("code=%v autoIndent=%v, want a code test from the host's flag", m.Game.Code, m.Game.AutoIndent)
+	}
+}
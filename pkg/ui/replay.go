@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-racer/pkg/game"
+)
+
+const replayTickInterval = 50 * time.Millisecond
+
+var replaySpeeds = []float64{0.5, 1, 2, 4, 8}
+
+// replayState tracks playback of a recorded run from the history
+type replayState struct {
+	Index   int           // Position of the run in Model.History
+	Elapsed time.Duration // Playback position within the run
+	Speed   float64
+	Paused  bool
+	id      int       // Identifies the active tick loop so stale ticks are dropped
+	last    time.Time // Wall time of the previous tick
+
+	playback *game.Playback // The run as far as it has been played
+}
+
+type replayTickMsg struct {
+	id int
+	at time.Time
+}
+
+func replayTick(id int) tea.Cmd {
+	return tea.Tick(replayTickInterval, func(t time.Time) tea.Msg {
+		return replayTickMsg{id: id, at: t}
+	})
+}
+
+// findReplay walks the history from index in direction dir and returns the
+// first run that has a keystroke log, or -1 if there is none
+func (m Model) findReplay(index, dir int) int {
+	for i := index; i >= 0 && i < len(m.History); i += dir {
+		if len(m.History[i].Keystrokes) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// startReplay begins playback of the closest recorded run at or before index
+func (m Model) startReplay(index int) (tea.Model, tea.Cmd) {
+	i := m.findReplay(index, -1)
+	if i < 0 {
+		return m, nil
+	}
+
+	speed := m.Replay.Speed
+	if speed == 0 {
+		speed = 1
+	}
+
+	run := m.History[i]
+	m.ShowReplay = true
+	m.Replay = replayState{
+		Index:    i,
+		Speed:    speed,
+		id:       m.Replay.id + 1,
+		last:     time.Now(),
+		playback: game.NewPlayback(run.Text, run.Keystrokes),
+	}
+	return m, replayTick(m.Replay.id)
+}
+
+func (m Model) updateReplay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "v":
+		m.ShowReplay = false
+		m.Replay.id++ // Orphan the running tick loop
+	case " ":
+		m.Replay.Paused = !m.Replay.Paused
+	case "+", "=":
+		m.Replay.Speed = nextSpeed(m.Replay.Speed, 1)
+	case "-":
+		m.Replay.Speed = nextSpeed(m.Replay.Speed, -1)
+	case "enter":
+		return m.startReplay(m.Replay.Index)
+	case "[":
+		if i := m.findReplay(m.Replay.Index-1, -1); i >= 0 {
+			return m.startReplay(i)
+		}
+	case "]":
+		if i := m.findReplay(m.Replay.Index+1, 1); i >= 0 {
+			return m.startReplay(i)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) tickReplay(msg replayTickMsg) (tea.Model, tea.Cmd) {
+	if !m.ShowReplay || msg.id != m.Replay.id {
+		return m, nil
+	}
+
+	if !m.Replay.Paused {
+		m.Replay.Elapsed += time.Duration(float64(msg.at.Sub(m.Replay.last)) * m.Replay.Speed)
+	}
+	m.Replay.last = msg.at
+
+	total := m.History[m.Replay.Index].Duration()
+	if m.Replay.Elapsed >= total {
+		m.Replay.Elapsed = total
+		return m, nil
+	}
+	return m, replayTick(m.Replay.id)
+}
+
+// nextSpeed steps through replaySpeeds in direction dir, clamping at either end
+func nextSpeed(current float64, dir int) float64 {
+	for i, speed := range replaySpeeds {
+		if speed == current {
+			next := i + dir
+			if next < 0 || next >= len(replaySpeeds) {
+				return current
+			}
+			return replaySpeeds[next]
+		}
+	}
+	return 1
+}
+
+func (m Model) renderReplay() string {
+	run := m.History[m.Replay.Index]
+	g := m.Replay.playback.At(m.Replay.Elapsed)
+
+	var s strings.Builder
+
+	title := "Replay"
+	if run.Plugin != "" {
+		title += " - " + run.Plugin
+	}
+	title += " (" + time.Unix(run.Timestamp, 0).Format("2006-01-02 15:04") + ")"
+	s.WriteString(TitleStyle.Render(title))
+	s.WriteString("\n\n")
+
+	s.WriteString(m.renderTypingText(g, -1))
+	s.WriteString("\n\n")
+
+	status := fmt.Sprintf("%s / %s   WPM: %.2f   Speed: %gx",
+		formatClock(m.Replay.Elapsed), formatClock(run.Duration()), g.WPM(), m.Replay.Speed)
+	if m.Replay.Paused {
+		status += "   (paused)"
+	}
+	s.WriteString(status)
+	s.WriteString("\n\n")
+	s.WriteString(UntypedStyle.Render("Space: pause  +/-: speed  Enter: restart  [/]: older/newer run  v/Esc: return"))
+
+	return s.String()
+}
+
+// formatClock formats a duration as mm:ss.t
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%04.1f", int(d.Minutes()), d.Seconds()-float64(int(d.Minutes())*60))
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"go-racer/pkg/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+func replayModel() Model {
+	return Model{
+		Config: &config.Config{},
+		History: []config.GameResult{
+			{
+				WPM: 30, Accuracy: 100, Timestamp: 1, Plugin: "hn", Text: "go",
+				Keystrokes: []config.Keystroke{
+					{Kind: config.KeystrokeInsert, Rune: "g", At: 100 * time.Millisecond},
+					{Kind: config.KeystrokeInsert, Rune: "o", At: 200 * time.Millisecond},
+					{Kind: config.KeystrokeFinish, At: 200 * time.Millisecond},
+				},
+			},
+			// Older results have no keystroke log and cannot be replayed
+			{WPM: 40, Accuracy: 100, Timestamp: 2},
+		},
+	}
+}
+
+func TestStartReplay_SkipsRunsWithoutLog(t *testing.T) {
+	m := replayModel()
+
+	next, cmd := m.startReplay(len(m.History) - 1)
+	m = next.(Model)
+
+	if !m.ShowReplay {
+		t.Fatal("expected replay to be shown")
+	}
+	if m.Replay.Index != 0 {
+		t.Errorf("Replay.Index = %d, want 0", m.Replay.Index)
+	}
+	if cmd == nil {
+		t.Error("expected a tick command")
+	}
+}
+
+func TestTickReplay(t *testing.T) {
+	m := replayModel()
+	next, _ := m.startReplay(0)
+	m = next.(Model)
+	m.Replay.Speed = 2
+
+	// 50ms of wall time at 2x advances the replay by 100ms
+	next, cmd := m.tickReplay(replayTickMsg{id: m.Replay.id, at: m.Replay.last.Add(50 * time.Millisecond)})
+	m = next.(Model)
+	if m.Replay.Elapsed != 100*time.Millisecond {
+		t.Errorf("Elapsed = %v, want 100ms", m.Replay.Elapsed)
+	}
+	if cmd == nil {
+		t.Error("expected playback to continue")
+	}
+
+	if !strings.Contains(m.renderReplay(), "Replay - hn") {
+		t.Error("replay view should name the plugin")
+	}
+	if got := m.Replay.playback.At(m.Replay.Elapsed).UserInput; got != "g" {
+		t.Errorf("replayed input = %q, want %q at 100ms", got, "g")
+	}
+
+	// Ticks from an abandoned loop are ignored
+	next, _ = m.tickReplay(replayTickMsg{id: m.Replay.id - 1, at: m.Replay.last.Add(time.Second)})
+	if next.(Model).Replay.Elapsed != m.Replay.Elapsed {
+		t.Error("stale tick should not advance the replay")
+	}
+
+	// Playback stops at the end of the run
+	next, cmd = m.tickReplay(replayTickMsg{id: m.Replay.id, at: m.Replay.last.Add(time.Second)})
+	m = next.(Model)
+	if m.Replay.Elapsed != 200*time.Millisecond {
+		t.Errorf("Elapsed = %v, want 200ms", m.Replay.Elapsed)
+	}
+	if cmd != nil {
+		t.Error("expected playback to stop at the end")
+	}
+}
@@ -8,6 +8,7 @@ var (
 	ErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000")) // Red
 	UntypedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#888888")) // Grey
 	CursorStyle  = lipgloss.NewStyle().Underline(true)                       // Underline
+	GhostStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#5F5FAF")) // Purple block
 	TitleStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFFFFF")).MarginBottom(1)
 	ResultsStyle = lipgloss.NewStyle().Padding(1, 2).Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("#888888"))
 )
@@ -8,8 +8,9 @@ import (
 )
 
 func TestRenderTrend(t *testing.T) {
-	// Create a dummy config with history
-	cfg := &config.Config{
+	// Create a dummy model with history
+	m := Model{
+		Config: &config.Config{},
 		History: []config.GameResult{
 			{WPM: 10, Accuracy: 100, Timestamp: 1},
 			{WPM: 20, Accuracy: 100, Timestamp: 2},
@@ -17,11 +18,7 @@ func TestRenderTrend(t *testing.T) {
 			{WPM: 40, Accuracy: 100, Timestamp: 4},
 			{WPM: 50, Accuracy: 100, Timestamp: 5},
 		},
-	}
-
-	m := Model{
-		Config: cfg,
-		width:  100, // Sufficient width
+		width: 100, // Sufficient width
 	}
 
 	// We can't access renderTrend directly if it is private, but in the same package (ui) we can.
@@ -47,12 +44,9 @@ func TestRenderTrend(t *testing.T) {
 }
 
 func TestRenderTrend_Empty(t *testing.T) {
-	cfg := &config.Config{
-		History: []config.GameResult{},
-	}
-
 	m := Model{
-		Config: cfg,
+		Config:  &config.Config{},
+		History: []config.GameResult{},
 	}
 
 	output := m.renderTrend()
@@ -62,18 +56,35 @@ func TestRenderTrend_Empty(t *testing.T) {
 }
 
 func TestRenderTrend_NotEnoughData(t *testing.T) {
-	cfg := &config.Config{
+	m := Model{
+		Config: &config.Config{},
 		History: []config.GameResult{
 			{WPM: 10, Accuracy: 100, Timestamp: 1},
 		},
 	}
 
+	output := m.renderTrend()
+	if !strings.Contains(output, "Not enough data") {
+		t.Error("Should display 'Not enough data' for single game history")
+	}
+}
+
+func TestRenderTrend_FiltersByMode(t *testing.T) {
 	m := Model{
-		Config: cfg,
+		Config: &config.Config{Mode: config.ModeTime, TimeLimit: 30},
+		History: []config.GameResult{
+			{WPM: 10, Accuracy: 100, Timestamp: 1},
+			{WPM: 20, Accuracy: 100, Timestamp: 2},
+			{WPM: 30, Accuracy: 100, Timestamp: 3, Mode: "time:30"},
+			{WPM: 40, Accuracy: 100, Timestamp: 4, Mode: "time:60"},
+		},
 	}
 
 	output := m.renderTrend()
+	if !strings.Contains(output, "30s") {
+		t.Error("Title should name the time limit")
+	}
 	if !strings.Contains(output, "Not enough data") {
-		t.Error("Should display 'Not enough data' for single game history")
+		t.Error("Only one 30s game was played, so there should not be enough data")
 	}
 }
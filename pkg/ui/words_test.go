@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"go-racer/pkg/config"
+	"go-racer/pkg/plugins"
+	"strings"
+	"testing"
+)
+
+// stubSource returns its items in order, cycling when it runs out
+type stubSource struct {
+	items []string
+	calls int
+}
+
+func (s *stubSource) Name() string        { return "Stub" }
+func (s *stubSource) Description() string { return "Test content" }
+
+func (s *stubSource) GetContent(context.Context) (*plugins.Content, error) {
+	text := s.items[s.calls%len(s.items)]
+	s.calls++
+	return &plugins.Content{
+		Text:      text,
+		SourceURL: fmt.Sprintf("https://example.com/%d", s.calls),
+	}, nil
+}
+
+func wordsConfig(n int) *config.Config {
+	return &config.Config{
+		IncludeNumbers:          true,
+		IncludePunctuation:      true,
+		IncludeCapitalLetters:   true,
+		IncludeNonStandardChars: true,
+		Mode:                    config.ModeWords,
+		WordCount:               n,
+	}
+}
+
+func TestLoadWords_StitchesAndTrims(t *testing.T) {
+	src := &stubSource{items: []string{"one two three", "four five", "six seven eight"}}
+	m := Model{Plugin: src, Config: wordsConfig(6)}
+
+	got := m.loadContent(context.Background())
+	msg, ok := got.(contentMsg)
+	if !ok {
+		t.Fatalf("expected contentMsg, got %#v", got)
+	}
+
+	if want := "one two three four five six"; msg.content.Text != want {
+		t.Errorf("Text = %q, want %q", msg.content.Text, want)
+	}
+	if len(msg.sources) != 3 {
+		t.Fatalf("got %d sources, want 3", len(msg.sources))
+	}
+	for i, src := range msg.sources {
+		if want := fmt.Sprintf("https://example.com/%d", i+1); src.SourceURL != want {
+			t.Errorf("source %d URL = %q, want %q", i, src.SourceURL, want)
+		}
+	}
+}
+
+func TestLoadWords_GivesUpOnEmptySource(t *testing.T) {
+	src := &stubSource{items: []string{""}}
+	m := Model{Plugin: src, Config: wordsConfig(10)}
+
+	msg, ok := m.loadContent(context.Background()).(errorMsg)
+	if !ok {
+		t.Fatal("expected an error for a source with no text")
+	}
+	if !strings.Contains(msg.err.Error(), "0 of 10 words") {
+		t.Errorf("unexpected error: %v", msg.err)
+	}
+}